@@ -0,0 +1,98 @@
+package config
+
+import (
+	"ddns/internal"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+)
+
+func New() *cobra.Command {
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the config file",
+		Long:  `Inspect the config file`,
+	}
+
+	config.AddCommand(
+		newValidate(),
+		newShow(),
+	)
+
+	return config
+}
+
+func newValidate() *cobra.Command {
+	return &cobra.Command{
+		Use:         "validate",
+		Short:       "Validate the config file and environment",
+		Long:        `Strictly decode the config file rejecting unknown fields, apply the environment and validate the result`,
+		Annotations: map[string]string{internal.SkipGatherConfigAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return validate(cmd.OutOrStdout(), internal.ResolveConfigPath(cmd.Flag("config").Value.String(), cmd.Flag("config").Changed))
+		},
+	}
+}
+
+func newShow() *cobra.Command {
+	return &cobra.Command{
+		Use:         "show",
+		Short:       "Show the effective config with secrets redacted",
+		Long:        `Show the effective config merged from defaults, the config file and the environment with secrets redacted`,
+		Annotations: map[string]string{internal.SkipGatherConfigAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return show(cmd.OutOrStdout(), internal.ResolveConfigPath(cmd.Flag("config").Value.String(), cmd.Flag("config").Changed))
+		},
+	}
+}
+
+func validate(w io.Writer, configPath string) error {
+	_, err := internal.ValidateConfigFile(configPath)
+
+	source := "the config at " + configPath
+	if configPath == "" {
+		source = "the config from the environment"
+	}
+
+	var errs internal.ConfigErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			location := configPath
+			if configPath == "" {
+				location = "environment"
+			} else if e.Line > 0 {
+				location = fmt.Sprintf("%s:%d", configPath, e.Line)
+			}
+			if e.Path != "" {
+				fmt.Fprintf(w, "%s: %s: %s\n", location, e.Path, e.Message)
+			} else {
+				fmt.Fprintf(w, "%s: %s\n", location, e.Message)
+			}
+		}
+		return fmt.Errorf("%s has %d error(s)", source, len(errs))
+	} else if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s is valid\n", strings.ToUpper(source[:1])+source[1:])
+	return err
+}
+
+func show(w io.Writer, configPath string) error {
+	c, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	e := yaml.NewEncoder(w)
+	e.SetIndent(2)
+	if err := e.Encode(internal.RedactedConfig(c)); err != nil {
+		return err
+	}
+	return e.Close()
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"ddns/cmd/config"
+	"ddns/cmd/plan"
 	"ddns/cmd/run"
 	"ddns/cmd/serve"
 	"ddns/internal"
@@ -14,6 +16,7 @@ import (
 )
 
 func New(_ io.Writer, _ io.Reader, _ []string, version string) *cobra.Command {
+	var logLevel, configPath string
 	cmd := &cobra.Command{
 		Use:   "ddns",
 		Short: "The DDNS CLI lets you interact with the DDNS service",
@@ -21,45 +24,62 @@ func New(_ io.Writer, _ io.Reader, _ []string, version string) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return errors.New("no additional command provided")
 		},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			initConfig(cmd, version, logLevel, configPath)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			internal.ShutdownTracing()
+		},
 		Version: version,
 	}
 
-	var logLevel, configPath string
-	cobra.OnInitialize(initConfig(&version, &logLevel, &configPath))
 	cmd.PersistentFlags().StringVar(&logLevel, "loglevel", "info", "log level, possible values: trace, debug, info, warn, error, fatal, panic")
-	cmd.PersistentFlags().StringVar(&configPath, "config", "./config.yml", "relative or absolute path to the config file")
+	cmd.PersistentFlags().StringVar(&configPath, "config", internal.DefaultConfigPath, "relative or absolute path to the config file, overrides DDNS_CONFIG, skipped if missing at the default path or empty")
 	cmd.InitDefaultVersionFlag()
 
 	cmd.AddCommand(
 		serve.New(),
 		run.New(),
+		plan.New(),
+		config.New(),
 	)
 
 	return cmd
 }
 
-func initConfig(version, logLevel, configPath *string) func() {
+func initConfig(cmd *cobra.Command, version, logLevel, configPath string) {
+	// Initialize Logging
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 
-	return func() {
-		// Initialize Logging
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	if l, err := zerolog.ParseLevel(logLevel); err == nil {
+		zerolog.SetGlobalLevel(l)
+	} else {
+		log.Error().Msgf("Could not parse log level '%s', defaulting to 'info'", logLevel)
+	}
+	log.Info().Msgf("Running DDNS version %s", version)
 
-		if l, err := zerolog.ParseLevel(*logLevel); err == nil {
-			zerolog.SetGlobalLevel(l)
-		} else {
-			log.Error().Msgf("Could not parse log level '%s', defaulting to 'info'", *logLevel)
-		}
-		log.Info().Msgf("Running DDNS version %s", *version)
+	// Commands inspecting the config file gather it themselves
+	if _, ok := cmd.Annotations[internal.SkipGatherConfigAnnotation]; ok {
+		return
+	}
 
-		// Initialize Config
-		err := internal.GatherConfig(*configPath)
-		if err != nil {
-			log.Fatal().Msgf("Error while loading the config file at %s: %s", *configPath, err)
-		}
+	// Initialize Config
+	path := internal.ResolveConfigPath(configPath, cmd.Flag("config").Changed)
+	if path == "" {
+		log.Info().Msg("No config file, using the defaults and the environment")
+	}
+	err := internal.GatherConfig(path)
+	if err != nil {
+		log.Fatal().Msgf("Error while loading the config file at %s: %s", path, err)
+	}
 
-		// Initialize Metric
-		internal.VersionGauge.WithLabelValues(*version, runtime.Version()).Set(1)
-		now := time.Now()
-		internal.StartTimeGauge.WithLabelValues().Set(float64(now.Unix()))
+	// Initialize Tracing
+	if err := internal.InitTracing(&internal.GetConfig().TracingConfig, version); err != nil {
+		log.Fatal().Msgf("Error while initializing tracing: %s", err)
 	}
+
+	// Initialize Metric
+	internal.VersionGauge.WithLabelValues(version, runtime.Version()).Set(1)
+	now := time.Now()
+	internal.StartTimeGauge.WithLabelValues().Set(float64(now.Unix()))
 }
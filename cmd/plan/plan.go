@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"context"
+	"ddns/internal"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"io"
+)
+
+func New() *cobra.Command {
+	var output string
+
+	start := &cobra.Command{
+		Use:   "plan",
+		Short: "Show the A record updates a synchronization would perform",
+		Long:  `Show the A record updates a synchronization would perform without performing them`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plan(cmd.OutOrStdout(), output)
+		},
+	}
+	start.Flags().StringVarP(&output, "output", "o", "text", "output format, possible values: text, json")
+
+	return start
+}
+
+func plan(w io.Writer, output string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format '%s'", output)
+	}
+
+	c := internal.GetConfig()
+
+	i := internal.IPAddressProviderFactory(c)
+	if i == nil {
+		log.Fatal().Msgf("no IPAddressProvider was configured and enabled")
+	}
+
+	d := internal.DNSProviderFactory(c)
+	if d == nil {
+		log.Fatal().Msgf("no DNSProvider was configured and enabled")
+	}
+
+	p, err := internal.CreatePlan(context.Background(), i, d)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return p.WriteJSON(w)
+	}
+	return p.WriteText(w)
+}
@@ -7,19 +7,22 @@ import (
 )
 
 func New() *cobra.Command {
+	var dryRun bool
+
 	start := &cobra.Command{
 		Use:   "run",
 		Short: "Run A record synchronization once",
 		Long:  `Run A record synchronization once`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run()
+			return run(dryRun)
 		},
 	}
+	start.Flags().BoolVar(&dryRun, "dry-run", false, "log the planned A record updates without performing them")
 
 	return start
 }
 
-func run() error {
+func run(dryRun bool) error {
 	c := internal.GetConfig()
 
 	// Start Synchronization
@@ -33,7 +36,10 @@ func run() error {
 		log.Fatal().Msgf("no DNSProvider was configured and enabled")
 	}
 
-	if err := internal.SyncRecords(i, d)(); err != nil {
+	s := internal.NewSyncer(c, i, d, dryRun)
+
+	if err := s.Sync(); err != nil {
+		internal.ShutdownTracing()
 		log.Fatal().Msg(err.Error())
 	}
 	return nil
@@ -10,25 +10,59 @@ import (
 )
 
 func New() *cobra.Command {
+	var dryRun bool
+
 	start := &cobra.Command{
 		Use:   "serve",
 		Short: "Serve daemon that periodically performs A record synchronization",
 		Long:  `Serve daemon that periodically performs A record synchronization`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return serve()
+			return serve(dryRun, cmd.Root().Version, internal.ResolveConfigPath(cmd.Flag("config").Value.String(), cmd.Flag("config").Changed))
 		},
 	}
+	start.Flags().BoolVar(&dryRun, "dry-run", false, "log the planned A record updates without performing them")
 
 	return start
 }
 
-func serve() error {
+func serve(dryRun bool, version string, configPath string) error {
 	c := internal.GetConfig()
+	if err := c.Validate(); err != nil {
+		log.Fatal().Msgf("Invalid config: %s", err)
+	}
+
+	// Initialize Providers
+	i := internal.IPAddressProviderFactory(c)
+	if i == nil {
+		log.Fatal().Msgf("no IPAddressProvider was configured and enabled")
+	}
 
-	// Initialize Metrics Handler
-	if c.MetricsServerConfig.Enable {
+	d := internal.DNSProviderFactory(c)
+	if d == nil {
+		log.Fatal().Msgf("no DNSProvider was configured and enabled")
+	}
+
+	var events <-chan struct{}
+	if c.NetlinkEventsConfig.Enable {
+		e, err := internal.WatchAddressChanges(&c.NetlinkEventsConfig)
+		if err != nil {
+			log.Fatal().Msgf("Could not listen for netlink events: %s", err)
+		}
+		events = e
+	}
+
+	scheduler := internal.NewScheduler(c, internal.NewSyncer(c, i, d, dryRun), events)
+
+	// Initialize Metrics, Health and Control API Handlers
+	if c.MetricsServerConfig.Enable || c.ControlAPIConfig.Enable {
 		router := httprouter.New()
-		router.GET("/metrics", internal.Metrics())
+		internal.RegisterHealth(router, scheduler, &c.HealthConfig)
+		if c.MetricsServerConfig.Enable {
+			router.GET("/metrics", internal.Metrics())
+		}
+		if c.ControlAPIConfig.Enable {
+			internal.RegisterControlAPI(router, scheduler, &c.ControlAPIConfig)
+		}
 
 		ch := make(chan bool)
 		go func() {
@@ -40,19 +74,35 @@ func serve() error {
 			}
 		}()
 		<-ch
+	} else {
+		log.Warn().Msg("Neither the metrics server nor the control API is enabled, /healthz and /readyz are not served")
 	}
 
-	// Start Main Loop
-	i := internal.IPAddressProviderFactory(c)
-	if i == nil {
-		log.Fatal().Msgf("no IPAddressProvider was configured and enabled")
+	// Initialize DNS Server
+	if c.DNSServerConfig.Enable {
+		// Validation ensures the dns server is the only enabled DNSProvider
+		s := internal.NewDNSServer(&c.DNSServerConfig, d.(*internal.DNSServerDNSProvider))
+		if err := s.Start(); err != nil {
+			log.Fatal().Msgf("Could not listen on %s: %s", c.DNSServerConfig.Listen, err)
+		}
+		defer s.Shutdown()
 	}
 
-	d := internal.DNSProviderFactory(c)
-	if d == nil {
-		log.Fatal().Msgf("no DNSProvider was configured and enabled")
+	// Initialize MQTT Publisher
+	if c.MQTTConfig.Enable {
+		p := internal.NewMQTTPublisher(&c.MQTTConfig, scheduler, version)
+		if err := p.Start(); err != nil {
+			log.Fatal().Msgf("Could not start publishing to mqtt broker %s: %s", c.MQTTConfig.Broker, err)
+		}
+		defer p.Stop()
 	}
 
-	internal.Retry(internal.SyncRecords(i, d), c.WaitInterval, c.RetryInterval)
+	// Initialize Config Reload
+	if err := internal.NewConfigReloader(configPath, scheduler).Watch(); err != nil {
+		log.Error().Msgf("Could not watch the config file at %s, reloading is disabled: %s", configPath, err)
+	}
+
+	// Start Main Loop
+	scheduler.Run()
 	return nil
 }
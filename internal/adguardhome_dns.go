@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// AdGuardHomeDNSProviderConfig Configuration for the AdGuard Home DNS rewrites Provider
+type AdGuardHomeDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_ADGUARDHOME_PROVIDER_ENABLE" required:"false"`
+
+	// Url of the AdGuard Home web interface
+	URL string `yaml:"url" envconfig:"DDNS_ADGUARDHOME_PROVIDER_URL" required:"false"`
+
+	// Username of the web interface
+	Username string `yaml:"username" envconfig:"DDNS_ADGUARDHOME_PROVIDER_USERNAME" required:"false"`
+
+	// Password of the web interface
+	Password string `yaml:"password" envconfig:"DDNS_ADGUARDHOME_PROVIDER_PASSWORD" required:"false" secret:"true"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_ADGUARDHOME_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultAdGuardHomeDNSProviderConfig = &AdGuardHomeDNSProviderConfig{
+	Enable:   false,
+	URL:      "http://127.0.0.1:3000",
+	Username: "",
+	Password: "",
+	ARecords: nil,
+}
+
+type adGuardHomeRewrite struct {
+	Domain string `json:"domain"`
+	Answer string `json:"answer"`
+}
+
+type adGuardHomeRewriteUpdate struct {
+	Target adGuardHomeRewrite `json:"target"`
+	Update adGuardHomeRewrite `json:"update"`
+}
+
+// AdGuardHomeDNSProvider AdGuard Home DNS rewrites Provider
+type AdGuardHomeDNSProvider struct {
+	url      string
+	username string
+	password string
+	aRecords []string
+	client   *http.Client
+}
+
+// NewAdGuardHomeDNSProvider Returns an instance of AdGuardHomeDNSProvider based on the passed configuration
+func NewAdGuardHomeDNSProvider(config *AdGuardHomeDNSProviderConfig) *AdGuardHomeDNSProvider {
+	return &AdGuardHomeDNSProvider{
+		url:      strings.TrimSuffix(config.URL, "/"),
+		username: config.Username,
+		password: config.Password,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the id is the domain of the rewrite and both are empty if a name has no rewrite to an IPv4 address yet
+func (a *AdGuardHomeDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var rewrites []adGuardHomeRewrite
+	if err := a.do(ctx, "GET", "/control/rewrite/list", nil, &rewrites); err != nil {
+		return nil, fmt.Errorf("could not list DNS rewrites: %w", err)
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range a.aRecords {
+		m := RecordAddressMapping{ARecord: name}
+		for _, r := range rewrites {
+			// Rewrites to other names or AAAA records are left alone
+			if strings.EqualFold(r.Domain, name) && net.ParseIP(r.Answer).To4() != nil {
+				m.ID = r.Domain
+				m.IPAddress = r.Answer
+				break
+			}
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Update the rewrite of the name to the provided ip address, the rewrite is added if it does not exist yet
+func (a *AdGuardHomeDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	if m.ID == "" {
+		if err := a.do(ctx, "POST", "/control/rewrite/add", adGuardHomeRewrite{Domain: m.ARecord, Answer: ipAddress}, nil); err != nil {
+			return fmt.Errorf("could not add DNS rewrite %s: %w", m.ARecord, err)
+		}
+		return nil
+	}
+
+	update := adGuardHomeRewriteUpdate{
+		Target: adGuardHomeRewrite{Domain: m.ID, Answer: m.IPAddress},
+		Update: adGuardHomeRewrite{Domain: m.ID, Answer: ipAddress},
+	}
+	if err := a.do(ctx, "PUT", "/control/rewrite/update", update, nil); err != nil {
+		return fmt.Errorf("could not update DNS rewrite %s: %w", m.ARecord, err)
+	}
+
+	return nil
+}
+
+// do Send a request with the json encoded payload to the API and decode the json response into v if not nil
+func (a *AdGuardHomeDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.url+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	if a.username != "" || a.password != "" {
+		req.SetBasicAuth(a.username, a.password)
+	}
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		// Errors are returned as plain text
+		if b, err := io.ReadAll(io.LimitReader(res.Body, 1024)); err == nil && len(bytes.TrimSpace(b)) > 0 {
+			return fmt.Errorf("response status code from %s was %s: %s", path, res.Status, bytes.TrimSpace(b))
+		}
+		return fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
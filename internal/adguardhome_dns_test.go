@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAdGuardHome An in-process stand-in for the AdGuard Home DNS rewrites API
+type fakeAdGuardHome struct {
+	mu       sync.Mutex
+	rewrites []adGuardHomeRewrite
+}
+
+func (f *fakeAdGuardHome) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "secret" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprintln(w, "Forbidden")
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/control/rewrite/list":
+		_ = json.NewEncoder(w).Encode(f.rewrites)
+	case r.Method == "POST" && r.URL.Path == "/control/rewrite/add":
+		var rewrite adGuardHomeRewrite
+		_ = json.NewDecoder(r.Body).Decode(&rewrite)
+		f.rewrites = append(f.rewrites, rewrite)
+	case r.Method == "PUT" && r.URL.Path == "/control/rewrite/update":
+		var update adGuardHomeRewriteUpdate
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		for i, rewrite := range f.rewrites {
+			if rewrite == update.Target {
+				f.rewrites[i] = update.Update
+				return
+			}
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintln(w, "target rule not found")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestAdGuardHomeDNSProvider tests that rewrites to IPv4 addresses are updated or added and other rewrites are kept
+func TestAdGuardHomeDNSProvider(t *testing.T) {
+	f := &fakeAdGuardHome{rewrites: []adGuardHomeRewrite{
+		{Domain: "Home.lan", Answer: "fd00::1"},
+		{Domain: "Home.lan", Answer: "10.0.0.1"},
+		{Domain: "www.lan", Answer: "home.lan"},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultAdGuardHomeDNSProviderConfig
+	c.URL = s.URL
+	c.Username = "admin"
+	c.Password = "secret"
+	c.ARecords = StringList{"home.lan", "www.lan"}
+	p := NewAdGuardHomeDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecordAddressMapping{
+		{ID: "Home.lan", ARecord: "home.lan", IPAddress: "10.0.0.1"},
+		{ID: "", ARecord: "www.lan", IPAddress: ""},
+	}
+	for i, m := range ms {
+		if m != want[i] {
+			t.Errorf("got %v, wanted %v", m, want[i])
+		}
+	}
+
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.2", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantRewrites := "Home.lan=fd00::1,Home.lan=10.0.0.2,www.lan=home.lan,www.lan=10.0.0.2"
+	var got []string
+	for _, rewrite := range f.rewrites {
+		got = append(got, rewrite.Domain+"="+rewrite.Answer)
+	}
+	if strings.Join(got, ",") != wantRewrites {
+		t.Errorf("got %s, wanted %s", strings.Join(got, ","), wantRewrites)
+	}
+
+	p.password = "wrong"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil || !strings.HasSuffix(err.Error(), "403 Forbidden: Forbidden") {
+		t.Errorf("got %v, wanted the error of the API", err)
+	}
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog/log"
+)
+
+// ControlAPIConfig Config section governing the http control api of the serve daemon
+type ControlAPIConfig struct {
+	// Switch to enable or disable the control api, served by the metrics http server
+	Enable bool `yaml:"enable" envconfig:"DDNS_API_ENABLE" required:"false"`
+
+	// Bearer token required to access the control api
+	Token string `yaml:"token" envconfig:"DDNS_API_TOKEN" required:"false" secret:"true"`
+}
+
+var defaultControlAPIConfig = &ControlAPIConfig{
+	Enable: false,
+	Token:  "",
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// RegisterControlAPI Registers the control api handlers for the Scheduler on the router
+func RegisterControlAPI(router *httprouter.Router, s *Scheduler, config *ControlAPIConfig) {
+	router.POST("/sync", authenticated(config.Token, SyncHandler(s)))
+	router.GET("/status", authenticated(config.Token, StatusHandler(s)))
+	router.GET("/records", authenticated(config.Token, RecordsHandler(s)))
+	router.POST("/pause", authenticated(config.Token, PauseHandler(s)))
+	router.POST("/resume", authenticated(config.Token, ResumeHandler(s)))
+}
+
+// SyncHandler Return a httprouter.Handle function that triggers an immediate synchronization
+func SyncHandler(s *Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !s.Trigger() {
+			writeJSON(w, http.StatusConflict, apiError{Error: "synchronization is paused"})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, s.Status())
+	}
+}
+
+// StatusHandler Return a httprouter.Handle function that returns the last report and the next scheduled run
+func StatusHandler(s *Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		writeJSON(w, http.StatusOK, s.Status())
+	}
+}
+
+// RecordsHandler Return a httprouter.Handle function that returns the managed A records and their current ip addresses
+func RecordsHandler(s *Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		writeJSON(w, http.StatusOK, s.Syncer().Records())
+	}
+}
+
+// PauseHandler Return a httprouter.Handle function that pauses synchronization
+func PauseHandler(s *Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		s.Pause()
+		writeJSON(w, http.StatusOK, s.Status())
+	}
+}
+
+// ResumeHandler Return a httprouter.Handle function that resumes synchronization
+func ResumeHandler(s *Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		s.Resume()
+		writeJSON(w, http.StatusOK, s.Status())
+	}
+}
+
+// authenticated Wraps the handle and rejects requests without the bearer token
+func authenticated(token string, h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, apiError{Error: "unauthorized"})
+			return
+		}
+		h(w, r, p)
+	}
+}
+
+// writeJSON Write the value as json response with the status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Msgf("error %s occurred while writing response body", err)
+	}
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func newTestControlAPI(t *testing.T) (*httprouter.Router, *Scheduler) {
+	c := defaultConfig
+	s := NewSyncer(&c, &fakeIPAddressProvider{address: "10.0.0.1"}, newFakeDNSProvider(), false)
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	scheduler := NewScheduler(&c, s, nil)
+	router := httprouter.New()
+	RegisterControlAPI(router, scheduler, &ControlAPIConfig{Enable: true, Token: "secret"})
+	return router, scheduler
+}
+
+func serveControlAPI(router *httprouter.Router, method string, path string, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestControlAPIUnauthorized tests that requests without the correct token are rejected
+func TestControlAPIUnauthorized(t *testing.T) {
+	router, _ := newTestControlAPI(t)
+
+	for _, token := range []string{"", "wrong"} {
+		if rr := serveControlAPI(router, "GET", "/status", token); rr.Code != http.StatusUnauthorized {
+			t.Errorf("got status %d for token %q, wanted %d", rr.Code, token, http.StatusUnauthorized)
+		}
+	}
+}
+
+// TestControlAPIRecords tests that the records endpoint returns the managed A records
+func TestControlAPIRecords(t *testing.T) {
+	router, _ := newTestControlAPI(t)
+
+	rr := serveControlAPI(router, "GET", "/records", "secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, wanted %d", rr.Code, http.StatusOK)
+	}
+
+	var records []RecordAddressMapping
+	if err := json.Unmarshal(rr.Body.Bytes(), &records); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(records) != 2 || records[0].ARecord != "example.com" || records[1].IPAddress != "10.0.0.1" {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
+
+// TestControlAPIPauseResume tests that synchronization can be paused and resumed
+func TestControlAPIPauseResume(t *testing.T) {
+	router, scheduler := newTestControlAPI(t)
+
+	if rr := serveControlAPI(router, "POST", "/pause", "secret"); rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, wanted %d", rr.Code, http.StatusOK)
+	}
+	if rr := serveControlAPI(router, "POST", "/sync", "secret"); rr.Code != http.StatusConflict {
+		t.Errorf("got status %d while paused, wanted %d", rr.Code, http.StatusConflict)
+	}
+
+	rr := serveControlAPI(router, "POST", "/resume", "secret")
+	var status SchedulerStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if status.Paused || scheduler.Paused() {
+		t.Error("expected synchronization to be resumed")
+	}
+	if status.LastReport == nil || status.LastReport.IPAddress != "10.0.0.1" {
+		t.Errorf("unexpected last report %+v", status.LastReport)
+	}
+
+	if rr := serveControlAPI(router, "POST", "/sync", "secret"); rr.Code != http.StatusAccepted {
+		t.Errorf("got status %d, wanted %d", rr.Code, http.StatusAccepted)
+	}
+}
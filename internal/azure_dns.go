@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// AzureDNSProviderConfig Configuration for the Azure DNS Provider
+type AzureDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_AZURE_PROVIDER_ENABLE" required:"false"`
+
+	// Microsoft Entra tenant id of the service principal
+	TenantID string `yaml:"tenantID" envconfig:"DDNS_AZURE_PROVIDER_TENANT_ID" required:"false"`
+
+	// Application (client) id of the service principal
+	ClientID string `yaml:"clientID" envconfig:"DDNS_AZURE_PROVIDER_CLIENT_ID" required:"false"`
+
+	// Client secret of the service principal
+	ClientSecret string `yaml:"clientSecret" envconfig:"DDNS_AZURE_PROVIDER_CLIENT_SECRET" required:"false" secret:"true"`
+
+	// Subscription id of the dns zone
+	SubscriptionID string `yaml:"subscriptionID" envconfig:"DDNS_AZURE_PROVIDER_SUBSCRIPTION_ID" required:"false"`
+
+	// Resource group of the dns zone
+	ResourceGroup string `yaml:"resourceGroup" envconfig:"DDNS_AZURE_PROVIDER_RESOURCE_GROUP" required:"false"`
+
+	// Name of the dns zone the A records belong to
+	Zone string `yaml:"zone" envconfig:"DDNS_AZURE_PROVIDER_ZONE" required:"false"`
+
+	// TTL of created A records in seconds, existing record sets keep their TTL
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_AZURE_PROVIDER_TTL" required:"false"`
+
+	// Url of the Azure Resource Manager API
+	ResourceManagerURL string `yaml:"resourceManagerURL" envconfig:"DDNS_AZURE_PROVIDER_RESOURCE_MANAGER_URL" required:"false"`
+
+	// Url of the Microsoft Entra authority the token is requested from
+	AuthorityURL string `yaml:"authorityURL" envconfig:"DDNS_AZURE_PROVIDER_AUTHORITY_URL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_AZURE_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultAzureDNSProviderConfig = &AzureDNSProviderConfig{
+	Enable:             false,
+	TenantID:           "",
+	ClientID:           "",
+	ClientSecret:       "",
+	SubscriptionID:     "",
+	ResourceGroup:      "",
+	Zone:               "",
+	TTL:                300,
+	ResourceManagerURL: "https://management.azure.com",
+	AuthorityURL:       "https://login.microsoftonline.com",
+	ARecords:           nil,
+}
+
+// azureDNSAPIVersion Version of the Microsoft.Network/dnsZones API used
+const azureDNSAPIVersion = "2018-05-01"
+
+type azureRecordSet struct {
+	Properties azureRecordSetProperties `json:"properties"`
+}
+
+type azureRecordSetProperties struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	TTL      int64             `json:"TTL"`
+	ARecords []azureARecord    `json:"ARecords"`
+}
+
+type azureARecord struct {
+	IPv4Address string `json:"ipv4Address"`
+}
+
+// AzureDNSProvider Azure DNS Provider
+type AzureDNSProvider struct {
+	subscriptionID     string
+	resourceGroup      string
+	zone               string
+	ttl                int64
+	resourceManagerURL string
+	aRecords           []string
+	tokens             *oauthTokenSource
+	client             *http.Client
+}
+
+// NewAzureDNSProvider Returns an instance of AzureDNSProvider based on the passed configuration
+func NewAzureDNSProvider(config *AzureDNSProviderConfig) *AzureDNSProvider {
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	resourceManagerURL := strings.TrimSuffix(config.ResourceManagerURL, "/")
+
+	return &AzureDNSProvider{
+		subscriptionID:     config.SubscriptionID,
+		resourceGroup:      config.ResourceGroup,
+		zone:               strings.TrimSuffix(config.Zone, "."),
+		ttl:                config.TTL,
+		resourceManagerURL: resourceManagerURL,
+		aRecords:           config.ARecords,
+		client:             client,
+		tokens: &oauthTokenSource{
+			client:   client,
+			tokenURL: strings.TrimSuffix(config.AuthorityURL, "/") + "/" + url.PathEscape(config.TenantID) + "/oauth2/v2.0/token",
+			form: func() (url.Values, error) {
+				return url.Values{
+					"grant_type":    {"client_credentials"},
+					"client_id":     {config.ClientID},
+					"client_secret": {config.ClientSecret},
+					"scope":         {resourceManagerURL + "/.default"},
+				}, nil
+			},
+		},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the ip address is empty if a name has no A record set yet
+func (a *AzureDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range a.aRecords {
+		rs, err := a.getRecordSet(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := RecordAddressMapping{ID: relativeRecordName(name, a.zone), ARecord: name}
+		if rs != nil && len(rs.Properties.ARecords) > 0 {
+			m.IPAddress = rs.Properties.ARecords[0].IPv4Address
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress PUT the A record set of the name with the provided ip address, the TTL and metadata of an existing set are kept
+func (a *AzureDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	rs, err := a.getRecordSet(ctx, m.ARecord)
+	if err != nil {
+		return err
+	}
+	if rs == nil {
+		rs = &azureRecordSet{Properties: azureRecordSetProperties{TTL: a.ttl}}
+	}
+	rs.Properties.ARecords = []azureARecord{{IPv4Address: ipAddress}}
+
+	if _, err := a.do(ctx, "PUT", a.recordSetPath(m.ARecord), rs, nil); err != nil {
+		return fmt.Errorf("could not put A record set %s: %w", m.ARecord, err)
+	}
+
+	return nil
+}
+
+// getRecordSet Returns the A record set of the name or nil if it does not exist
+func (a *AzureDNSProvider) getRecordSet(ctx context.Context, name string) (*azureRecordSet, error) {
+	var rs azureRecordSet
+	status, err := a.do(ctx, "GET", a.recordSetPath(name), nil, &rs)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get A record set %s: %w", name, err)
+	}
+	return &rs, nil
+}
+
+func (a *AzureDNSProvider) recordSetPath(name string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/dnsZones/%s/A/%s?api-version=%s",
+		url.PathEscape(a.subscriptionID), url.PathEscape(a.resourceGroup), url.PathEscape(a.zone),
+		url.PathEscape(relativeRecordName(name, a.zone)), azureDNSAPIVersion)
+}
+
+// do Send an authorized request with the json encoded payload to the API and decode the json response into v if not nil,
+// the status code is returned alongside errors
+func (a *AzureDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) (int, error) {
+	token, err := a.tokens.Token(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not obtain an access token: %w", err)
+	}
+
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.resourceManagerURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	// Record sets are created with 201
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		var e struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Error.Code != "" {
+			return res.StatusCode, fmt.Errorf("response status code was %s: %s: %s", res.Status, e.Error.Code, e.Error.Message)
+		}
+		return res.StatusCode, fmt.Errorf("response status code was %s, not 200", res.Status)
+	}
+
+	if v == nil {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAzure An in-process stand-in for the Microsoft Entra token endpoint and the Azure DNS record set API
+type fakeAzure struct {
+	mu            sync.Mutex
+	tokenRequests int
+	recordSets    map[string]azureRecordSet
+	puts          int
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/tenant-1/oauth2/v2.0/token" {
+		f.tokenRequests++
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("client_id") != "client-1" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "Invalid client secret provided."})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "eyJ0.test", "expires_in": 3599, "token_type": "Bearer"})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer eyJ0.test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const zonePath = "/subscriptions/sub-1/resourceGroups/dns/providers/Microsoft.Network/dnsZones/example.com/A/"
+	name, ok := strings.CutPrefix(r.URL.Path, zonePath)
+	if !ok || r.URL.Query().Get("api-version") != azureDNSAPIVersion {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		rs, ok := f.recordSets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"code": "NotFound", "message": "The resource record was not found."}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(rs)
+	case "PUT":
+		var rs azureRecordSet
+		_ = json.NewDecoder(r.Body).Decode(&rs)
+		f.puts++
+		if _, ok := f.recordSets[name]; !ok {
+			w.WriteHeader(http.StatusCreated)
+		}
+		f.recordSets[name] = rs
+		_ = json.NewEncoder(w).Encode(rs)
+	}
+}
+
+// TestAzureDNSProvider tests that tokens are obtained with client credentials and record sets are PUT keeping TTL and metadata
+func TestAzureDNSProvider(t *testing.T) {
+	f := &fakeAzure{recordSets: map[string]azureRecordSet{
+		"@":   {Properties: azureRecordSetProperties{TTL: 60, ARecords: []azureARecord{{IPv4Address: "10.0.0.1"}}}},
+		"www": {Properties: azureRecordSetProperties{TTL: 3600, Metadata: map[string]string{"owner": "ops"}, ARecords: []azureARecord{{IPv4Address: "10.0.0.2"}}}},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultAzureDNSProviderConfig
+	c.TenantID = "tenant-1"
+	c.ClientID = "client-1"
+	c.ClientSecret = "secret"
+	c.SubscriptionID = "sub-1"
+	c.ResourceGroup = "dns"
+	c.Zone = "example.com"
+	c.ResourceManagerURL = s.URL
+	c.AuthorityURL = s.URL
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewAzureDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, m := range ms[1:] {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	www := f.recordSets["www"].Properties
+	if len(www.ARecords) != 1 || www.ARecords[0].IPv4Address != "10.0.0.1" || www.TTL != 3600 || www.Metadata["owner"] != "ops" {
+		t.Errorf("got %v, wanted 10.0.0.1 with ttl 3600 and the metadata kept", www)
+	}
+	created := f.recordSets["new"].Properties
+	if len(created.ARecords) != 1 || created.ARecords[0].IPv4Address != "10.0.0.1" || created.TTL != 300 {
+		t.Errorf("got %v, wanted 10.0.0.1 with ttl 300", created)
+	}
+	if f.puts != 2 || f.tokenRequests != 1 {
+		t.Errorf("got %d puts and %d token requests, wanted 2 and 1", f.puts, f.tokenRequests)
+	}
+
+	c.ClientSecret = "wrong"
+	_, err = NewAzureDNSProvider(&c).GetARecordAddresses(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_client: Invalid client secret provided.") {
+		t.Errorf("got %v, wanted invalid_client", err)
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // CloudflareDNSProviderConfig Configuration for Cloudflare DNS Provider
@@ -18,13 +20,13 @@ type CloudflareDNSProviderConfig struct {
 	Enable bool `yaml:"enable" envconfig:"DDNS_CLOUDFLARE_PROVIDER_ENABLE" required:"false"`
 
 	// Cloudflare API Token with "All zones - DNS:Read, DNS:Edit" permissions
-	APIToken string `yaml:"apiToken" envconfig:"DDNS_CLOUDFLARE_API_TOKEN" required:"false"`
+	APIToken string `yaml:"apiToken" envconfig:"DDNS_CLOUDFLARE_API_TOKEN" required:"false" secret:"true"`
 
 	// Cloudflare Zone ID
 	ZoneID string `yaml:"zoneID" envconfig:"DDNS_CLOUDFLARE_PROVIDER_ZONE_ID" required:"false"`
 
 	// List of A Records
-	ARecords []string `yaml:"aRecords" envconfig:"DDNS_CLOUDFLARE_PROVIDER_RECORDS" required:"false"`
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_CLOUDFLARE_PROVIDER_RECORDS" required:"false"`
 }
 
 type cloudflareListRecordsResponse struct {
@@ -74,6 +76,7 @@ type CloudflareDNSProvider struct {
 	apiToken string
 	zoneID   string
 	aRecords []string
+	client   *http.Client
 }
 
 // NewCloudflareDNSProvider Returns an instance of CloudflareDNSProvider based on the passed configuration
@@ -82,15 +85,16 @@ func NewCloudflareDNSProvider(config *CloudflareDNSProviderConfig) *CloudflareDN
 		apiToken: config.APIToken,
 		zoneID:   config.ZoneID,
 		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
 	}
 }
 
 // GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration
-func (c *CloudflareDNSProvider) GetARecordAddresses() ([]RecordAddressMapping, error) {
+func (c *CloudflareDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
 	// Make request
 	requestURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", c.zoneID)
 
-	req, err := http.NewRequest("GET", requestURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +102,7 @@ func (c *CloudflareDNSProvider) GetARecordAddresses() ([]RecordAddressMapping, e
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +135,7 @@ func (c *CloudflareDNSProvider) GetARecordAddresses() ([]RecordAddressMapping, e
 }
 
 // SetARecordAddress Set the provided A record to the provided ip address
-func (c *CloudflareDNSProvider) SetARecordAddress(ipAddress string, m RecordAddressMapping) error {
+func (c *CloudflareDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
 	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
 
 	// Make request
@@ -148,7 +152,7 @@ func (c *CloudflareDNSProvider) SetARecordAddress(ipAddress string, m RecordAddr
 	}
 
 	log.Debug().Msgf("Executing put request against %s with payload %s", requestURL, jsonPayload)
-	req, err := http.NewRequest("PUT", requestURL, bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequestWithContext(ctx, "PUT", requestURL, bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return err
 	}
@@ -156,7 +160,7 @@ func (c *CloudflareDNSProvider) SetARecordAddress(ipAddress string, m RecordAddr
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -1,13 +1,53 @@
 package internal
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/kelseyhightower/envconfig"
 	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
 	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
 )
 
-var global Config
+var global atomic.Pointer[Config]
+
+// DefaultConfigPath Path of the config file used if neither --config nor DDNS_CONFIG is set, it is skipped if missing
+const DefaultConfigPath = "./config.yml"
+
+// ConfigPathEnv Environment variable holding the path of the config file
+const ConfigPathEnv = "DDNS_CONFIG"
+
+// SkipGatherConfigAnnotation Annotation of commands that gather the config file themselves instead of at startup
+const SkipGatherConfigAnnotation = "ddns/skip-gather-config"
+
+// StringList A list of strings, read from the environment as a comma, semicolon or whitespace separated list or a json array
+type StringList []string
+
+// Decode Parse the value of an environment variable, implements envconfig.Decoder
+func (l *StringList) Decode(value string) error {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var list []string
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return fmt.Errorf("invalid json array: %w", err)
+		}
+		*l = list
+		return nil
+	}
+
+	*l = strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || unicode.IsSpace(r)
+	})
+	return nil
+}
 
 // MetricsServerConfig Config section governing the metrics http server
 type MetricsServerConfig struct {
@@ -32,6 +72,39 @@ type Config struct {
 	// Config section governing the metrics http server
 	MetricsServerConfig MetricsServerConfig `yaml:"metricsServer"`
 
+	// Config section governing commands run before and after A records are updated
+	HooksConfig HooksConfig `yaml:"hooks"`
+
+	// Config section governing notifications about address changes and failures
+	NotificationsConfig NotificationsConfig `yaml:"notifications"`
+
+	// Config section governing OpenTelemetry tracing
+	TracingConfig TracingConfig `yaml:"tracing"`
+
+	// Config section governing publishing the state to an MQTT broker
+	MQTTConfig MQTTConfig `yaml:"mqtt"`
+
+	// Config section governing the liveness and readiness endpoints
+	HealthConfig HealthConfig `yaml:"health"`
+
+	// Config section governing the http control api of the serve daemon
+	ControlAPIConfig ControlAPIConfig `yaml:"controlAPI"`
+
+	// Config section governing synchronizations triggered by address changes
+	NetlinkEventsConfig NetlinkEventsConfig `yaml:"netlinkEvents"`
+
+	// Config section governing the built-in authoritative dns server of the serve daemon
+	DNSServerConfig DNSServerConfig `yaml:"dnsServer"`
+
+	// Config section governing the on-disk state file
+	StateStoreConfig StateStoreConfig `yaml:"stateStore"`
+
+	// Config section governing when a newly obtained ip address is published
+	StabilityConfig StabilityConfig `yaml:"stability"`
+
+	// Config section governing secret references resolved from HashiCorp Vault
+	VaultConfig VaultConfig `yaml:"vault"`
+
 	// Config section governing the static ip address provider
 	StaticIPAddressProviderConfig StaticIPAddressProviderConfig `yaml:"staticIPAddressProvider"`
 
@@ -40,6 +113,54 @@ type Config struct {
 
 	// Config section governing the url ip address provider
 	CloudflareDNSProviderConfig CloudflareDNSProviderConfig `yaml:"cloudflareDNSProvider"`
+
+	// Config section governing the RFC 2136 dynamic update dns provider
+	RFC2136DNSProviderConfig RFC2136DNSProviderConfig `yaml:"rfc2136DNSProvider"`
+
+	// Config section governing the dyndns2 protocol dns provider
+	DynDNS2DNSProviderConfig DynDNS2DNSProviderConfig `yaml:"dyndns2DNSProvider"`
+
+	// Config section governing the aws route 53 dns provider
+	Route53DNSProviderConfig Route53DNSProviderConfig `yaml:"route53DNSProvider"`
+
+	// Config section governing the hetzner dns provider
+	HetznerDNSProviderConfig HetznerDNSProviderConfig `yaml:"hetznerDNSProvider"`
+
+	// Config section governing the digitalocean dns provider
+	DigitalOceanDNSProviderConfig DigitalOceanDNSProviderConfig `yaml:"digitalOceanDNSProvider"`
+
+	// Config section governing the powerdns dns provider
+	PowerDNSDNSProviderConfig PowerDNSDNSProviderConfig `yaml:"powerDNSDNSProvider"`
+
+	// Config section governing the google cloud dns provider
+	GoogleCloudDNSProviderConfig GoogleCloudDNSProviderConfig `yaml:"googleCloudDNSProvider"`
+
+	// Config section governing the azure dns provider
+	AzureDNSProviderConfig AzureDNSProviderConfig `yaml:"azureDNSProvider"`
+
+	// Config section governing the desec dns provider
+	DeSECDNSProviderConfig DeSECDNSProviderConfig `yaml:"desecDNSProvider"`
+
+	// Config section governing the gandi livedns dns provider
+	GandiDNSProviderConfig GandiDNSProviderConfig `yaml:"gandiDNSProvider"`
+
+	// Config section governing the porkbun dns provider
+	PorkbunDNSProviderConfig PorkbunDNSProviderConfig `yaml:"porkbunDNSProvider"`
+
+	// Config section governing the ovhcloud dns provider
+	OVHDNSProviderConfig OVHDNSProviderConfig `yaml:"ovhDNSProvider"`
+
+	// Config section governing the pi-hole local dns records provider
+	PiHoleDNSProviderConfig PiHoleDNSProviderConfig `yaml:"piHoleDNSProvider"`
+
+	// Config section governing the adguard home dns rewrites provider
+	AdGuardHomeDNSProviderConfig AdGuardHomeDNSProviderConfig `yaml:"adGuardHomeDNSProvider"`
+
+	// Config section governing the hosts file dns provider
+	HostsFileDNSProviderConfig HostsFileDNSProviderConfig `yaml:"hostsFileDNSProvider"`
+
+	// Config section governing the zone file dns provider
+	ZoneFileDNSProviderConfig ZoneFileDNSProviderConfig `yaml:"zoneFileDNSProvider"`
 }
 
 var defaultMetricsServerConfig = &MetricsServerConfig{
@@ -52,21 +173,83 @@ var defaultConfig = Config{
 	WaitInterval:                  1 * time.Minute,
 	RetryInterval:                 5 * time.Second,
 	MetricsServerConfig:           *defaultMetricsServerConfig,
+	HooksConfig:                   *defaultHooksConfig,
+	NotificationsConfig:           *defaultNotificationsConfig,
+	TracingConfig:                 *defaultTracingConfig,
+	MQTTConfig:                    *defaultMQTTConfig,
+	HealthConfig:                  *defaultHealthConfig,
+	ControlAPIConfig:              *defaultControlAPIConfig,
+	NetlinkEventsConfig:           *defaultNetlinkEventsConfig,
+	DNSServerConfig:               *defaultDNSServerConfig,
+	StateStoreConfig:              *defaultStateStoreConfig,
+	StabilityConfig:               *defaultStabilityConfig,
+	VaultConfig:                   *defaultVaultConfig,
 	URLIPAddressProviderConfig:    *defaultURLIPAddressProviderConfig,
 	StaticIPAddressProviderConfig: *defaultStaticIPAddressProviderConfig,
 	CloudflareDNSProviderConfig:   *defaultCloudflareDNSProviderConfig,
+	RFC2136DNSProviderConfig:      *defaultRFC2136DNSProviderConfig,
+	DynDNS2DNSProviderConfig:      *defaultDynDNS2DNSProviderConfig,
+	Route53DNSProviderConfig:      *defaultRoute53DNSProviderConfig,
+	HetznerDNSProviderConfig:      *defaultHetznerDNSProviderConfig,
+	DigitalOceanDNSProviderConfig: *defaultDigitalOceanDNSProviderConfig,
+	PowerDNSDNSProviderConfig:     *defaultPowerDNSDNSProviderConfig,
+	GoogleCloudDNSProviderConfig:  *defaultGoogleCloudDNSProviderConfig,
+	AzureDNSProviderConfig:        *defaultAzureDNSProviderConfig,
+	DeSECDNSProviderConfig:        *defaultDeSECDNSProviderConfig,
+	GandiDNSProviderConfig:        *defaultGandiDNSProviderConfig,
+	PorkbunDNSProviderConfig:      *defaultPorkbunDNSProviderConfig,
+	OVHDNSProviderConfig:          *defaultOVHDNSProviderConfig,
+	PiHoleDNSProviderConfig:       *defaultPiHoleDNSProviderConfig,
+	AdGuardHomeDNSProviderConfig:  *defaultAdGuardHomeDNSProviderConfig,
+	HostsFileDNSProviderConfig:    *defaultHostsFileDNSProviderConfig,
+	ZoneFileDNSProviderConfig:     *defaultZoneFileDNSProviderConfig,
 }
 
 // GatherConfig sets the globalConfig with values read from the passed config file and the environment
 func GatherConfig(configPath string) error {
-	global = defaultConfig
-	if err := gatherFromFile(configPath); err != nil {
+	c, err := LoadConfig(configPath)
+	if err != nil {
 		return err
 	}
-	return gatherFromEnv()
+	global.Store(c)
+	return nil
+}
+
+// ResolveConfigPath returns the path of the config file, an explicitly set --config flag takes precedence over DDNS_CONFIG,
+// an empty path means no config file is read
+func ResolveConfigPath(flag string, flagChanged bool) string {
+	if flagChanged {
+		return flag
+	}
+	if p, ok := os.LookupEnv(ConfigPathEnv); ok {
+		return p
+	}
+	if _, err := os.Stat(DefaultConfigPath); errors.Is(err, fs.ErrNotExist) {
+		return ""
+	}
+	return DefaultConfigPath
+}
+
+// LoadConfig returns a new config with values read from the passed config file and the environment, the globalConfig is left untouched.
+// Values from the environment take precedence over the file, which takes precedence over the defaults. No file is read if the path is empty.
+// Secret references are resolved on every call.
+func LoadConfig(configPath string) (*Config, error) {
+	c := defaultConfig
+	if configPath != "" {
+		if err := gatherFromFile(&c, configPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := gatherFromEnv(&c); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
 }
 
-func gatherFromFile(configPath string) error {
+func gatherFromFile(c *Config, configPath string) error {
 	file, err := os.Open(configPath)
 	if err != nil {
 		return err
@@ -75,14 +258,58 @@ func gatherFromFile(configPath string) error {
 	defer file.Close()
 	d := yaml.NewDecoder(file)
 
-	return d.Decode(&global)
+	// An empty file leaves the defaults untouched
+	if err := d.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func gatherFromEnv(c *Config) error {
+	return envconfig.Process("", c)
 }
 
-func gatherFromEnv() error {
-	return envconfig.Process("", &global)
+// Fingerprint returns a hash of the enabled ip address provider and dns provider sections including their A records,
+// used to detect config changes between runs that require a reconciliation with the dns provider
+func (c *Config) Fingerprint() string {
+	sections := map[string]any{}
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if !strings.HasSuffix(name, "IPAddressProvider") && !strings.HasSuffix(name, "DNSProvider") && name != "dnsServer" {
+			continue
+		}
+		if enable := v.Field(i).FieldByName("Enable"); enable.Kind() == reflect.Bool && enable.Bool() {
+			sections[name] = v.Field(i).Interface()
+		}
+	}
+
+	b, err := json.Marshal(sections)
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// ARecords returns the A records of the enabled dns provider section
+func (c *Config) ARecords() []string {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if !strings.HasSuffix(name, "DNSProvider") && name != "dnsServer" {
+			continue
+		}
+		if enable := v.Field(i).FieldByName("Enable"); enable.Kind() == reflect.Bool && enable.Bool() {
+			if records, ok := v.Field(i).FieldByName("ARecords").Interface().(StringList); ok {
+				return records
+			}
+		}
+	}
+	return nil
 }
 
 // GetConfig returns the globalConfig
 func GetConfig() *Config {
-	return &global
+	return global.Load()
 }
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestStringListDecode tests that lists from the environment are split on separators and parsed as json arrays
+func TestStringListDecode(t *testing.T) {
+	for value, want := range map[string]StringList{
+		"a.example.com":                          {"a.example.com"},
+		"a.example.com,b.example.com":            {"a.example.com", "b.example.com"},
+		" a.example.com, b.example.com;\nc.com ": {"a.example.com", "b.example.com", "c.com"},
+		`["a.example.com", "b.example.com"]`:     {"a.example.com", "b.example.com"},
+		"":                                       {},
+	} {
+		var got StringList
+		if err := got.Decode(value); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("got %q, wanted %q for %q", got, want, value)
+		}
+	}
+
+	var l StringList
+	if err := l.Decode(`["a.example.com"`); err == nil {
+		t.Error("expected an error for an invalid json array")
+	}
+}
+
+// TestResolveConfigPath tests that the flag takes precedence over DDNS_CONFIG and a missing default path is skipped
+func TestResolveConfigPath(t *testing.T) {
+	if got := ResolveConfigPath(DefaultConfigPath, false); got != "" {
+		t.Errorf("got %s, wanted no config file", got)
+	}
+
+	t.Setenv(ConfigPathEnv, "/etc/ddns/env.yml")
+	if got := ResolveConfigPath(DefaultConfigPath, false); got != "/etc/ddns/env.yml" {
+		t.Errorf("got %s, wanted %s", got, "/etc/ddns/env.yml")
+	}
+	if got := ResolveConfigPath("/etc/ddns/flag.yml", true); got != "/etc/ddns/flag.yml" {
+		t.Errorf("got %s, wanted %s", got, "/etc/ddns/flag.yml")
+	}
+	if got := ResolveConfigPath("", true); got != "" {
+		t.Errorf("got %s, wanted no config file", got)
+	}
+}
+
+// TestLoadConfigPrecedence tests that the environment takes precedence over the file, which takes precedence over the defaults
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "waitInterval: 2m\nretryInterval: 20s\ncloudflareDNSProvider:\n  aRecords:\n    - file.example.com\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DDNS_RETRY_INTERVAL", "30s")
+	t.Setenv("DDNS_CLOUDFLARE_PROVIDER_RECORDS", "a.example.com, b.example.com")
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if c.WaitInterval != 2*time.Minute {
+		t.Errorf("got %s, wanted %s from the file", c.WaitInterval, 2*time.Minute)
+	}
+	if c.RetryInterval != 30*time.Second {
+		t.Errorf("got %s, wanted %s from the environment", c.RetryInterval, 30*time.Second)
+	}
+	if want := (StringList{"a.example.com", "b.example.com"}); !reflect.DeepEqual(c.CloudflareDNSProviderConfig.ARecords, want) {
+		t.Errorf("got %q, wanted %q from the environment", c.CloudflareDNSProviderConfig.ARecords, want)
+	}
+	if c.MetricsServerConfig.Port != defaultMetricsServerConfig.Port {
+		t.Errorf("got %s, wanted the default %s", c.MetricsServerConfig.Port, defaultMetricsServerConfig.Port)
+	}
+}
+
+// TestLoadConfigWithoutFile tests that the config is gathered from the defaults and the environment only if the path is empty
+func TestLoadConfigWithoutFile(t *testing.T) {
+	t.Setenv("DDNS_WAIT_INTERVAL", "3m")
+
+	c, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if c.WaitInterval != 3*time.Minute {
+		t.Errorf("got %s, wanted %s", c.WaitInterval, 3*time.Minute)
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("expected an error for an explicitly set missing file")
+	}
+}
+
+// TestLoadConfigEmptyFile tests that an empty config file leaves the defaults untouched
+func TestLoadConfigEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if c.WaitInterval != defaultConfig.WaitInterval {
+		t.Errorf("got %s, wanted %s", c.WaitInterval, defaultConfig.WaitInterval)
+	}
+}
@@ -1,77 +1,131 @@
 package internal
 
 import (
-	"github.com/rs/zerolog/log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
-	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type RecordAddressMapping struct {
-	ID        string
-	ARecord   string
-	IPAddress string
+	ID        string `json:"id"`
+	ARecord   string `json:"aRecord"`
+	IPAddress string `json:"ipAddress"`
 }
 
 type IPAddressProvider interface {
 	// GetIPAddress Get the current ip address from provider
-	GetIPAddress() (*string, error)
+	GetIPAddress(context.Context) (*string, error)
 }
 
 type DNSProvider interface {
 	// GetARecordAddresses Get the ip addresses which are currently set for the A records
-	GetARecordAddresses() ([]RecordAddressMapping, error)
+	GetARecordAddresses(context.Context) ([]RecordAddressMapping, error)
 
 	// SetARecordAddress Set the current ip address for the provided A record
-	SetARecordAddress(string, RecordAddressMapping) error
+	SetARecordAddress(context.Context, string, RecordAddressMapping) error
+}
+
+// BatchDNSProvider A DNSProvider that sets several A records in a single request, the Syncer passes all planned updates
+// of a synchronization at once instead of calling SetARecordAddress for each of them
+type BatchDNSProvider interface {
+	DNSProvider
+
+	// SetARecordAddresses Set the current ip address for the provided A records, returns one error per A record that is
+	// nil if it was set
+	SetARecordAddresses(context.Context, string, []RecordAddressMapping) []error
+}
+
+// PlannedRecord The current and desired ip address of a single A record
+type PlannedRecord struct {
+	ID               string `json:"id"`
+	ARecord          string `json:"aRecord"`
+	CurrentIPAddress string `json:"currentIPAddress"`
+	DesiredIPAddress string `json:"desiredIPAddress"`
+	Update           bool   `json:"update"`
+	Suppressed       string `json:"suppressed,omitempty"`
 }
 
-type Retryable func() error
-
-// Retry Repeatedly run the Retryable function and wait between successful and failed attempts
-func Retry(retryable Retryable, waitInterval time.Duration, retryInterval time.Duration) {
-	for {
-		err := retryable()
-		if err == nil {
-			log.Info().Msgf("Success. Next attempt in %s", waitInterval)
-			time.Sleep(waitInterval)
-		} else {
-			log.Error().Msgf("An error occurred: %s. Retrying in %s", err, retryInterval)
-			time.Sleep(retryInterval)
+// Plan The changes required to bring the A records in line with the obtained ip address
+type Plan struct {
+	IPAddress string          `json:"ipAddress"`
+	Records   []PlannedRecord `json:"records"`
+}
+
+// Updates Return only the records of the plan that require an update
+func (p *Plan) Updates() []PlannedRecord {
+	var updates []PlannedRecord
+	for _, r := range p.Records {
+		if r.Update {
+			updates = append(updates, r)
 		}
 	}
+	return updates
 }
 
-// SyncRecords Updates the A records if required
-func SyncRecords(i IPAddressProvider, d DNSProvider) func() error {
-	return func() error {
-		addressToSet, err := i.GetIPAddress()
-		if err != nil {
-			return err
-		}
-		log.Info().Msgf("Obtained ip address was %s", *addressToSet)
+// WriteText Write a human readable representation of the plan to the writer
+func (p *Plan) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "Obtained ip address: %s\n", p.IPAddress); err != nil {
+		return err
+	}
 
-		setAddresses, err := d.GetARecordAddresses()
-		if err != nil {
+	for _, r := range p.Records {
+		line := fmt.Sprintf("  %s: %s (no change)\n", r.ARecord, r.CurrentIPAddress)
+		if r.Suppressed != "" {
+			line = fmt.Sprintf("! %s: %s -> %s (suppressed: %s)\n", r.ARecord, r.CurrentIPAddress, r.DesiredIPAddress, r.Suppressed)
+		} else if r.Update {
+			line = fmt.Sprintf("~ %s: %s -> %s\n", r.ARecord, r.CurrentIPAddress, r.DesiredIPAddress)
+		}
+		if _, err := io.WriteString(w, line); err != nil {
 			return err
 		}
+	}
 
-		for _, addr := range setAddresses {
-			log.Info().Msgf("A record for %s is currently set to %s", addr.ARecord, addr.IPAddress)
-
-			if *addressToSet != addr.IPAddress {
-				log.Info().Msg("Ip address of A record did not match obtained address")
-				if err := d.SetARecordAddress(*addressToSet, addr); err != nil {
-					return err
-				}
-				DNSARecordInfoGauge.WithLabelValues(*addressToSet, addr.ARecord).Set(1)
-			} else {
-				log.Info().Msgf("Ip address of A record matched obtained address, no update required")
-				DNSARecordInfoGauge.WithLabelValues(addr.IPAddress, addr.ARecord).Set(1)
-			}
-		}
+	_, err := fmt.Fprintf(w, "Plan: %d to update, %d unchanged\n", len(p.Updates()), len(p.Records)-len(p.Updates()))
+	return err
+}
+
+// WriteJSON Write the plan as indented json to the writer
+func (p *Plan) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(p)
+}
+
+// CreatePlan Compare the obtained ip address with the current A records without changing anything
+func CreatePlan(ctx context.Context, i IPAddressProvider, d DNSProvider) (*Plan, error) {
+	addressToSet, err := i.GetIPAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msgf("Obtained ip address was %s", *addressToSet)
+
+	return createPlan(ctx, *addressToSet, d)
+}
+
+// createPlan Compare the passed ip address with the current A records of the DNSProvider
+func createPlan(ctx context.Context, addressToSet string, d DNSProvider) (*Plan, error) {
+	setAddresses, err := d.GetARecordAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
+	p := &Plan{IPAddress: addressToSet, Records: []PlannedRecord{}}
+	for _, addr := range setAddresses {
+		log.Info().Msgf("A record for %s is currently set to %s", addr.ARecord, addr.IPAddress)
+		p.Records = append(p.Records, PlannedRecord{
+			ID:               addr.ID,
+			ARecord:          addr.ARecord,
+			CurrentIPAddress: addr.IPAddress,
+			DesiredIPAddress: addressToSet,
+			Update:           addressToSet != addr.IPAddress,
+		})
 	}
+
+	return p, nil
 }
 
 // IPAddressProviderFactory Returns an instance of IPAddressProvider based on the passed configuration
@@ -92,7 +146,68 @@ func DNSProviderFactory(c *Config) DNSProvider {
 	if c.CloudflareDNSProviderConfig.Enable {
 		log.Debug().Msgf("Using CloudflareDNSProvider as DNSProvider with records %s", strings.Join(c.CloudflareDNSProviderConfig.ARecords, ","))
 		return NewCloudflareDNSProvider(&c.CloudflareDNSProviderConfig)
+	} else if c.RFC2136DNSProviderConfig.Enable {
+		log.Debug().Msgf("Using RFC2136DNSProvider as DNSProvider with server %s and records %s", c.RFC2136DNSProviderConfig.Server, strings.Join(c.RFC2136DNSProviderConfig.ARecords, ","))
+		return NewRFC2136DNSProvider(&c.RFC2136DNSProviderConfig)
+	} else if c.DynDNS2DNSProviderConfig.Enable {
+		log.Debug().Msgf("Using DynDNS2DNSProvider as DNSProvider with url %s and records %s", c.DynDNS2DNSProviderConfig.URL, strings.Join(c.DynDNS2DNSProviderConfig.ARecords, ","))
+		return NewDynDNS2DNSProvider(&c.DynDNS2DNSProviderConfig)
+	} else if c.Route53DNSProviderConfig.Enable {
+		log.Debug().Msgf("Using Route53DNSProvider as DNSProvider with hosted zone %s and records %s", c.Route53DNSProviderConfig.HostedZoneID, strings.Join(c.Route53DNSProviderConfig.ARecords, ","))
+		return NewRoute53DNSProvider(&c.Route53DNSProviderConfig)
+	} else if c.HetznerDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using HetznerDNSProvider as DNSProvider with zone %s and records %s", c.HetznerDNSProviderConfig.ZoneID, strings.Join(c.HetznerDNSProviderConfig.ARecords, ","))
+		return NewHetznerDNSProvider(&c.HetznerDNSProviderConfig)
+	} else if c.DigitalOceanDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using DigitalOceanDNSProvider as DNSProvider with domain %s and records %s", c.DigitalOceanDNSProviderConfig.Domain, strings.Join(c.DigitalOceanDNSProviderConfig.ARecords, ","))
+		return NewDigitalOceanDNSProvider(&c.DigitalOceanDNSProviderConfig)
+	} else if c.PowerDNSDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using PowerDNSDNSProvider as DNSProvider with zone %s and records %s", c.PowerDNSDNSProviderConfig.Zone, strings.Join(c.PowerDNSDNSProviderConfig.ARecords, ","))
+		return NewPowerDNSDNSProvider(&c.PowerDNSDNSProviderConfig)
+	} else if c.GoogleCloudDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using GoogleCloudDNSProvider as DNSProvider with managed zone %s and records %s", c.GoogleCloudDNSProviderConfig.ManagedZone, strings.Join(c.GoogleCloudDNSProviderConfig.ARecords, ","))
+		return NewGoogleCloudDNSProvider(&c.GoogleCloudDNSProviderConfig)
+	} else if c.AzureDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using AzureDNSProvider as DNSProvider with zone %s and records %s", c.AzureDNSProviderConfig.Zone, strings.Join(c.AzureDNSProviderConfig.ARecords, ","))
+		return NewAzureDNSProvider(&c.AzureDNSProviderConfig)
+	} else if c.DeSECDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using DeSECDNSProvider as DNSProvider with domain %s and records %s", c.DeSECDNSProviderConfig.Domain, strings.Join(c.DeSECDNSProviderConfig.ARecords, ","))
+		return NewDeSECDNSProvider(&c.DeSECDNSProviderConfig)
+	} else if c.GandiDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using GandiDNSProvider as DNSProvider with domain %s and records %s", c.GandiDNSProviderConfig.Domain, strings.Join(c.GandiDNSProviderConfig.ARecords, ","))
+		return NewGandiDNSProvider(&c.GandiDNSProviderConfig)
+	} else if c.PorkbunDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using PorkbunDNSProvider as DNSProvider with domain %s and records %s", c.PorkbunDNSProviderConfig.Domain, strings.Join(c.PorkbunDNSProviderConfig.ARecords, ","))
+		return NewPorkbunDNSProvider(&c.PorkbunDNSProviderConfig)
+	} else if c.OVHDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using OVHDNSProvider as DNSProvider with zone %s and records %s", c.OVHDNSProviderConfig.Zone, strings.Join(c.OVHDNSProviderConfig.ARecords, ","))
+		return NewOVHDNSProvider(&c.OVHDNSProviderConfig)
+	} else if c.PiHoleDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using PiHoleDNSProvider as DNSProvider with url %s and records %s", c.PiHoleDNSProviderConfig.URL, strings.Join(c.PiHoleDNSProviderConfig.ARecords, ","))
+		return NewPiHoleDNSProvider(&c.PiHoleDNSProviderConfig)
+	} else if c.AdGuardHomeDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using AdGuardHomeDNSProvider as DNSProvider with url %s and records %s", c.AdGuardHomeDNSProviderConfig.URL, strings.Join(c.AdGuardHomeDNSProviderConfig.ARecords, ","))
+		return NewAdGuardHomeDNSProvider(&c.AdGuardHomeDNSProviderConfig)
+	} else if c.HostsFileDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using HostsFileDNSProvider as DNSProvider with file %s and records %s", c.HostsFileDNSProviderConfig.Path, strings.Join(c.HostsFileDNSProviderConfig.ARecords, ","))
+		return NewHostsFileDNSProvider(&c.HostsFileDNSProviderConfig)
+	} else if c.ZoneFileDNSProviderConfig.Enable {
+		log.Debug().Msgf("Using ZoneFileDNSProvider as DNSProvider with file %s and records %s", c.ZoneFileDNSProviderConfig.Path, strings.Join(c.ZoneFileDNSProviderConfig.ARecords, ","))
+		return NewZoneFileDNSProvider(&c.ZoneFileDNSProviderConfig)
+	} else if c.DNSServerConfig.Enable {
+		log.Debug().Msgf("Using DNSServerDNSProvider as DNSProvider with zone %s and records %s", c.DNSServerConfig.Zone, strings.Join(c.DNSServerConfig.ARecords, ","))
+		return NewDNSServerDNSProvider(&c.DNSServerConfig, &c.StateStoreConfig)
 	}
 
 	return nil
 }
+
+// relativeRecordName Returns the name relative to the zone as used by record APIs, @ for the zone apex
+func relativeRecordName(name string, zone string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if name == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeIPAddressProvider struct {
+	address string
+	err     error
+	calls   int
+}
+
+func (f *fakeIPAddressProvider) GetIPAddress(_ context.Context) (*string, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	a := f.address
+	return &a, nil
+}
+
+type fakeDNSProvider struct {
+	records  []RecordAddressMapping
+	err      error
+	gets     int
+	sets     []RecordAddressMapping
+	setError error
+}
+
+func (f *fakeDNSProvider) GetARecordAddresses(_ context.Context) ([]RecordAddressMapping, error) {
+	f.gets++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]RecordAddressMapping{}, f.records...), nil
+}
+
+func (f *fakeDNSProvider) SetARecordAddress(_ context.Context, ipAddress string, m RecordAddressMapping) error {
+	if f.setError != nil {
+		return f.setError
+	}
+	f.sets = append(f.sets, RecordAddressMapping{ID: m.ID, ARecord: m.ARecord, IPAddress: ipAddress})
+	for idx := range f.records {
+		if f.records[idx].ARecord == m.ARecord {
+			f.records[idx].IPAddress = ipAddress
+		}
+	}
+	return nil
+}
+
+func newFakeDNSProvider() *fakeDNSProvider {
+	return &fakeDNSProvider{records: []RecordAddressMapping{
+		{ID: "1", ARecord: "example.com", IPAddress: "10.0.0.1"},
+		{ID: "2", ARecord: "www.example.com", IPAddress: "10.0.0.2"},
+	}}
+}
+
+// TestCreatePlan tests that only records with a differing ip address are marked for update
+func TestCreatePlan(t *testing.T) {
+	d := newFakeDNSProvider()
+	p, err := CreatePlan(context.Background(), &fakeIPAddressProvider{address: "10.0.0.1"}, d)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if len(p.Records) != 2 {
+		t.Fatalf("got %d records, wanted 2", len(p.Records))
+	}
+
+	updates := p.Updates()
+	if len(updates) != 1 || updates[0].ARecord != "www.example.com" || updates[0].CurrentIPAddress != "10.0.0.2" {
+		t.Errorf("unexpected updates %+v", updates)
+	}
+}
+
+// TestCreatePlanError tests that errors from the providers are returned
+func TestCreatePlanError(t *testing.T) {
+	_, err := CreatePlan(context.Background(), &fakeIPAddressProvider{err: errors.New("boom")}, newFakeDNSProvider())
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("got %v, wanted boom", err)
+	}
+}
+
+// TestSyncRecords tests that differing records are set to the obtained ip address
+func TestSyncRecords(t *testing.T) {
+	d := newFakeDNSProvider()
+	if err := NewSyncer(&defaultConfig, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if len(d.sets) != 2 {
+		t.Errorf("got %d updates, wanted 2", len(d.sets))
+	}
+}
+
+// fakeBatchDNSProvider A fakeDNSProvider that records the A records passed per SetARecordAddresses call
+type fakeBatchDNSProvider struct {
+	*fakeDNSProvider
+	batches [][]string
+}
+
+func (f *fakeBatchDNSProvider) SetARecordAddresses(ctx context.Context, ipAddress string, ms []RecordAddressMapping) []error {
+	var names []string
+	errs := make([]error, len(ms))
+	for idx, m := range ms {
+		names = append(names, m.ARecord)
+		errs[idx] = f.SetARecordAddress(ctx, ipAddress, m)
+	}
+	f.batches = append(f.batches, names)
+	return errs
+}
+
+// TestSyncRecordsBatch tests that only the planned records are passed to a BatchDNSProvider in a single call
+func TestSyncRecordsBatch(t *testing.T) {
+	d := &fakeBatchDNSProvider{fakeDNSProvider: newFakeDNSProvider()}
+	d.records = append(d.records, RecordAddressMapping{ID: "3", ARecord: "new.example.com", IPAddress: "10.0.0.3"})
+	if err := NewSyncer(&defaultConfig, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if len(d.batches) != 1 || strings.Join(d.batches[0], " ") != "example.com www.example.com" {
+		t.Errorf("got batches %v, wanted one with example.com and www.example.com", d.batches)
+	}
+}
+
+// TestSyncRecordsDryRun tests that a dry run does not set any records
+func TestSyncRecordsDryRun(t *testing.T) {
+	d := newFakeDNSProvider()
+	if err := NewSyncer(&defaultConfig, &fakeIPAddressProvider{address: "10.0.0.3"}, d, true).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if len(d.sets) != 0 {
+		t.Errorf("got %d updates, wanted none", len(d.sets))
+	}
+}
+
+// TestPlanWrite tests the text and json representation of a plan
+func TestPlanWrite(t *testing.T) {
+	p, _ := CreatePlan(context.Background(), &fakeIPAddressProvider{address: "10.0.0.1"}, newFakeDNSProvider())
+
+	var text bytes.Buffer
+	if err := p.WriteText(&text); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !strings.Contains(text.String(), "~ www.example.com: 10.0.0.2 -> 10.0.0.1") {
+		t.Errorf("unexpected text output %s", text.String())
+	}
+
+	var js bytes.Buffer
+	if err := p.WriteJSON(&js); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	var decoded Plan
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if decoded.IPAddress != "10.0.0.1" || len(decoded.Records) != 2 {
+		t.Errorf("unexpected json output %s", js.String())
+	}
+}
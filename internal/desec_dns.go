@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// DeSECDNSProviderConfig Configuration for the deSEC DNS Provider
+type DeSECDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_DESEC_PROVIDER_ENABLE" required:"false"`
+
+	// deSEC API token
+	Token string `yaml:"token" envconfig:"DDNS_DESEC_PROVIDER_TOKEN" required:"false" secret:"true"`
+
+	// Domain the A records belong to
+	Domain string `yaml:"domain" envconfig:"DDNS_DESEC_PROVIDER_DOMAIN" required:"false"`
+
+	// Url of the deSEC API
+	BaseURL string `yaml:"baseURL" envconfig:"DDNS_DESEC_PROVIDER_BASE_URL" required:"false"`
+
+	// TTL of created A records in seconds, at least 3600, existing record sets keep their TTL
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_DESEC_PROVIDER_TTL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_DESEC_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultDeSECDNSProviderConfig = &DeSECDNSProviderConfig{
+	Enable:   false,
+	Token:    "",
+	Domain:   "",
+	BaseURL:  "https://desec.io/api/v1",
+	TTL:      3600,
+	ARecords: nil,
+}
+
+type deSECRRset struct {
+	Subname string   `json:"subname"`
+	Type    string   `json:"type"`
+	TTL     int64    `json:"ttl"`
+	Records []string `json:"records"`
+}
+
+// DeSECDNSProvider deSEC DNS Provider
+type DeSECDNSProvider struct {
+	token    string
+	domain   string
+	baseURL  string
+	ttl      int64
+	aRecords []string
+	client   *http.Client
+}
+
+// NewDeSECDNSProvider Returns an instance of DeSECDNSProvider based on the passed configuration
+func NewDeSECDNSProvider(config *DeSECDNSProviderConfig) *DeSECDNSProvider {
+	return &DeSECDNSProvider{
+		token:    config.Token,
+		domain:   strings.TrimSuffix(config.Domain, "."),
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		ttl:      config.TTL,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the ip address is empty if a name has no A record set yet
+func (d *DeSECDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range d.aRecords {
+		rrset, err := d.getRRset(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := RecordAddressMapping{ID: relativeRecordName(name, d.domain), ARecord: name}
+		if rrset != nil && len(rrset.Records) > 0 {
+			m.IPAddress = rrset.Records[0]
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Replace the A record set of the name with the provided ip address, the record set is created if it does
+// not exist yet
+func (d *DeSECDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	rrset, err := d.getRRset(ctx, m.ARecord)
+	if err != nil {
+		return err
+	}
+
+	ttl := d.ttl
+	if rrset != nil {
+		ttl = rrset.TTL
+	}
+
+	// The bulk endpoint creates missing record sets and replaces existing ones, the apex has an empty subname
+	subname := relativeRecordName(m.ARecord, d.domain)
+	if subname == "@" {
+		subname = ""
+	}
+	payload := []deSECRRset{{Subname: subname, Type: "A", TTL: ttl, Records: []string{ipAddress}}}
+
+	if _, err := d.do(ctx, "PUT", "/domains/"+url.PathEscape(d.domain)+"/rrsets/", payload, nil); err != nil {
+		return fmt.Errorf("could not put A record set %s: %w", m.ARecord, err)
+	}
+
+	return nil
+}
+
+// getRRset Returns the A record set of the name or nil if it does not exist
+func (d *DeSECDNSProvider) getRRset(ctx context.Context, name string) (*deSECRRset, error) {
+	path := "/domains/" + url.PathEscape(d.domain) + "/rrsets/" + url.PathEscape(relativeRecordName(name, d.domain)) + "/A/"
+
+	var rrset deSECRRset
+	status, err := d.do(ctx, "GET", path, nil, &rrset)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get A record set %s: %w", name, err)
+	}
+	return &rrset, nil
+}
+
+// do Send a request with the json encoded payload to the API and decode the json response into v if not nil,
+// the status code is returned alongside errors
+func (d *DeSECDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Token %s", d.token))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Detail string `json:"detail"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Detail != "" {
+			return res.StatusCode, fmt.Errorf("response status code from %s was %s: %s", path, res.Status, e.Detail)
+		}
+		return res.StatusCode, fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDeSEC An in-process stand-in for the deSEC RRset API of a single domain
+type fakeDeSEC struct {
+	mu     sync.Mutex
+	rrsets map[string]deSECRRset
+}
+
+func (f *fakeDeSEC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Token token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"detail": "Invalid token."})
+		return
+	}
+
+	const rrsetsPath = "/domains/example.com/rrsets/"
+	switch {
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, rrsetsPath) && strings.HasSuffix(r.URL.Path, "/A/"):
+		subname := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, rrsetsPath), "/A/")
+		if subname == "@" {
+			subname = ""
+		}
+		rrset, ok := f.rrsets[subname]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"detail": "Not found."})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(rrset)
+	case r.Method == "PUT" && r.URL.Path == rrsetsPath:
+		var rrsets []deSECRRset
+		_ = json.NewDecoder(r.Body).Decode(&rrsets)
+		for _, rrset := range rrsets {
+			f.rrsets[rrset.Subname] = rrset
+		}
+		_ = json.NewEncoder(w).Encode(rrsets)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestDeSECDNSProvider tests that RRsets are read by subname and replaced or created with the bulk endpoint
+func TestDeSECDNSProvider(t *testing.T) {
+	f := &fakeDeSEC{rrsets: map[string]deSECRRset{
+		"":    {Subname: "", Type: "A", TTL: 3600, Records: []string{"10.0.0.1"}},
+		"www": {Subname: "www", Type: "A", TTL: 7200, Records: []string{"10.0.0.2"}},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultDeSECDNSProviderConfig
+	c.Token = "token"
+	c.Domain = "example.com"
+	c.BaseURL = s.URL
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewDeSECDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.3", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantTTLs := map[string]int64{"": 3600, "www": 7200, "new": 3600}
+	for subname, ttl := range wantTTLs {
+		rrset := f.rrsets[subname]
+		if strings.Join(rrset.Records, ",") != "10.0.0.3" || rrset.TTL != ttl {
+			t.Errorf("got %v with ttl %d for %q, wanted 10.0.0.3 with ttl %d", rrset.Records, rrset.TTL, subname, ttl)
+		}
+	}
+
+	p.token = "wrong"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil || !strings.Contains(err.Error(), "Invalid token.") {
+		t.Errorf("got %v, wanted the error of the API", err)
+	}
+}
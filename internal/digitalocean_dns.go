@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// DigitalOceanDNSProviderConfig Configuration for the DigitalOcean DNS Provider
+type DigitalOceanDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_DIGITALOCEAN_PROVIDER_ENABLE" required:"false"`
+
+	// DigitalOcean personal access token with domain read and update scopes
+	APIToken string `yaml:"apiToken" envconfig:"DDNS_DIGITALOCEAN_PROVIDER_API_TOKEN" required:"false" secret:"true"`
+
+	// Domain the A records belong to
+	Domain string `yaml:"domain" envconfig:"DDNS_DIGITALOCEAN_PROVIDER_DOMAIN" required:"false"`
+
+	// Url of the DigitalOcean API
+	BaseURL string `yaml:"baseURL" envconfig:"DDNS_DIGITALOCEAN_PROVIDER_BASE_URL" required:"false"`
+
+	// TTL of the A records in seconds
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_DIGITALOCEAN_PROVIDER_TTL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_DIGITALOCEAN_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultDigitalOceanDNSProviderConfig = &DigitalOceanDNSProviderConfig{
+	Enable:   false,
+	APIToken: "",
+	Domain:   "",
+	BaseURL:  "https://api.digitalocean.com/v2",
+	TTL:      1800,
+	ARecords: nil,
+}
+
+type digitalOceanRecord struct {
+	ID   int64  `json:"id,omitempty"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+	TTL  int64  `json:"ttl"`
+}
+
+type digitalOceanListRecordsResponse struct {
+	DomainRecords []digitalOceanRecord `json:"domain_records"`
+	Links         struct {
+		Pages struct {
+			Next string `json:"next"`
+		} `json:"pages"`
+	} `json:"links"`
+}
+
+// digitalOceanPageSize Number of records requested per page
+const digitalOceanPageSize = 200
+
+// DigitalOceanDNSProvider DigitalOcean DNS Provider
+type DigitalOceanDNSProvider struct {
+	apiToken string
+	domain   string
+	baseURL  string
+	ttl      int64
+	aRecords []string
+	client   *http.Client
+}
+
+// NewDigitalOceanDNSProvider Returns an instance of DigitalOceanDNSProvider based on the passed configuration
+func NewDigitalOceanDNSProvider(config *DigitalOceanDNSProviderConfig) *DigitalOceanDNSProvider {
+	return &DigitalOceanDNSProvider{
+		apiToken: config.APIToken,
+		domain:   config.Domain,
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		ttl:      config.TTL,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the id and ip address are empty if a name has no A record yet
+func (d *DigitalOceanDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var records []digitalOceanRecord
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("type", "A")
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(digitalOceanPageSize))
+
+		var r digitalOceanListRecordsResponse
+		if err := d.do(ctx, "GET", d.recordsPath()+"?"+q.Encode(), nil, &r); err != nil {
+			return nil, fmt.Errorf("could not list records of domain %s: %w", d.domain, err)
+		}
+		records = append(records, r.DomainRecords...)
+
+		if r.Links.Pages.Next == "" || len(r.DomainRecords) == 0 {
+			break
+		}
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range d.aRecords {
+		m := RecordAddressMapping{ARecord: name}
+		for _, r := range records {
+			if r.Type == "A" && r.Name == relativeRecordName(name, d.domain) {
+				m.ID = strconv.FormatInt(r.ID, 10)
+				m.IPAddress = r.Data
+				break
+			}
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Update the A record to the provided ip address, the record is created if it does not exist yet
+func (d *DigitalOceanDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	record := digitalOceanRecord{
+		Type: "A",
+		Name: relativeRecordName(m.ARecord, d.domain),
+		Data: ipAddress,
+		TTL:  d.ttl,
+	}
+
+	if m.ID == "" {
+		return d.do(ctx, "POST", d.recordsPath(), record, nil)
+	}
+	return d.do(ctx, "PUT", d.recordsPath()+"/"+url.PathEscape(m.ID), record, nil)
+}
+
+func (d *DigitalOceanDNSProvider) recordsPath() string {
+	return "/domains/" + url.PathEscape(d.domain) + "/records"
+}
+
+// do Send a request with the json encoded payload to the API and decode the json response into v if not nil
+func (d *DigitalOceanDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", d.apiToken))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	// Records are created with 201
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("response status code from %s was %s, not 200 or 201", path, res.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeDigitalOceanDNS An in-process stand-in for the DigitalOcean domain record API returning two records per page
+type fakeDigitalOceanDNS struct {
+	mu      sync.Mutex
+	records []digitalOceanRecord
+	pages   int
+}
+
+func (f *fakeDigitalOceanDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/domains/example.com/records":
+		f.pages++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var res digitalOceanListRecordsResponse
+		for i := (page - 1) * 2; i < page*2 && i < len(f.records); i++ {
+			res.DomainRecords = append(res.DomainRecords, f.records[i])
+		}
+		if page*2 < len(f.records) {
+			res.Links.Pages.Next = fmt.Sprintf("https://api.digitalocean.com/v2/domains/example.com/records?page=%d", page+1)
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	case r.Method == "POST" && r.URL.Path == "/domains/example.com/records":
+		var record digitalOceanRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		record.ID = int64(len(f.records) + 1)
+		f.records = append(f.records, record)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"domain_record": record})
+	case r.Method == "PUT":
+		var record digitalOceanRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		for i := range f.records {
+			if fmt.Sprintf("/domains/example.com/records/%d", f.records[i].ID) == r.URL.Path {
+				record.ID = f.records[i].ID
+				f.records[i] = record
+				_ = json.NewEncoder(w).Encode(map[string]any{"domain_record": record})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestDigitalOceanDNSProvider tests that records are listed across pages, updated and created
+func TestDigitalOceanDNSProvider(t *testing.T) {
+	f := &fakeDigitalOceanDNS{records: []digitalOceanRecord{
+		{ID: 1, Type: "A", Name: "mail", Data: "10.0.0.9", TTL: 1800},
+		{ID: 2, Type: "A", Name: "www", Data: "10.0.0.2", TTL: 1800},
+		{ID: 3, Type: "A", Name: "@", Data: "10.0.0.1", TTL: 1800},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultDigitalOceanDNSProviderConfig
+	c.APIToken = "token"
+	c.Domain = "example.com"
+	c.BaseURL = s.URL
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewDigitalOceanDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.pages != 2 {
+		t.Errorf("got %d pages, wanted 2", f.pages)
+	}
+	want := []RecordAddressMapping{
+		{ID: "3", ARecord: "example.com", IPAddress: "10.0.0.1"},
+		{ID: "2", ARecord: "www.example.com", IPAddress: "10.0.0.2"},
+		{ID: "", ARecord: "new.example.com", IPAddress: ""},
+	}
+	for i, m := range ms {
+		if m != want[i] {
+			t.Errorf("got %v, wanted %v", m, want[i])
+		}
+	}
+
+	for _, m := range ms[1:] {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ms, err = p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if m.IPAddress != "10.0.0.1" || m.ID == "" {
+			t.Errorf("got %v, wanted %s with an id", m, "10.0.0.1")
+		}
+	}
+	if r := f.records[3]; r.Name != "new" || r.TTL != 1800 {
+		t.Errorf("got %s with ttl %d, wanted new with ttl 1800", r.Name, r.TTL)
+	}
+}
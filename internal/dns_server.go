@@ -0,0 +1,383 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+// DNSServerConfig Config section governing the built-in authoritative dns server of the serve daemon
+type DNSServerConfig struct {
+	// Switch to enable the dns server, which then takes the place of the dns provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_DNS_SERVER_ENABLE" required:"false"`
+
+	// Address the dns server listens on for udp and tcp queries
+	Listen string `yaml:"listen" envconfig:"DDNS_DNS_SERVER_LISTEN" required:"false"`
+
+	// Zone delegated to the dns server
+	Zone string `yaml:"zone" envconfig:"DDNS_DNS_SERVER_ZONE" required:"false"`
+
+	// Names of the authoritative name servers of the zone, the first one is the primary of the SOA record
+	Nameservers StringList `yaml:"nameservers" envconfig:"DDNS_DNS_SERVER_NAMESERVERS" required:"false"`
+
+	// Mailbox of the person responsible for the zone, hostmaster of the zone if empty
+	Hostmaster string `yaml:"hostmaster" envconfig:"DDNS_DNS_SERVER_HOSTMASTER" required:"false"`
+
+	// TTL of all answers in seconds
+	TTL uint32 `yaml:"ttl" envconfig:"DDNS_DNS_SERVER_TTL" required:"false"`
+
+	// Static TXT records by name
+	TXTRecords map[string]string `yaml:"txtRecords" envconfig:"DDNS_DNS_SERVER_TXT_RECORDS" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_DNS_SERVER_RECORDS" required:"false"`
+}
+
+var defaultDNSServerConfig = &DNSServerConfig{
+	Enable:      false,
+	Listen:      ":53",
+	Zone:        "",
+	Nameservers: nil,
+	Hostmaster:  "",
+	TTL:         60,
+	TXTRecords:  nil,
+	ARecords:    nil,
+}
+
+// DNSServerDNSProvider DNS Provider keeping the addresses served by the DNSServer in memory, so updates take effect
+// immediately. The DNSServer answers from the same instance, which is therefore kept across config reloads.
+type DNSServerDNSProvider struct {
+	aRecords []string
+
+	mu      sync.Mutex
+	records map[string]string
+}
+
+// NewDNSServerDNSProvider Returns an instance of DNSServerDNSProvider based on the passed configuration, the addresses
+// are initialized from the state file if it is enabled
+func NewDNSServerDNSProvider(config *DNSServerConfig, stateConfig *StateStoreConfig) *DNSServerDNSProvider {
+	d := &DNSServerDNSProvider{aRecords: config.ARecords, records: map[string]string{}}
+
+	if stateConfig.Enable {
+		state, err := NewStateStore(stateConfig).Load()
+		if err != nil {
+			log.Error().Msgf("Could not load state file, starting without addresses: %s", err)
+			return d
+		}
+		for name, r := range state.Records {
+			d.records[name] = r.Content
+		}
+	}
+
+	return d
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the addresses held in memory, the ip address is empty if a
+// name was not set yet
+func (d *DNSServerDNSProvider) GetARecordAddresses(_ context.Context) ([]RecordAddressMapping, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var ms []RecordAddressMapping
+	for _, name := range d.aRecords {
+		ms = append(ms, RecordAddressMapping{ID: dns.Fqdn(strings.ToLower(name)), ARecord: name, IPAddress: d.records[name]})
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Set the address of the name in memory
+func (d *DNSServerDNSProvider) SetARecordAddress(_ context.Context, ipAddress string, m RecordAddressMapping) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+	d.records[m.ARecord] = ipAddress
+
+	return nil
+}
+
+// addresses Returns the addresses of the names that were set by lower case fully qualified name
+func (d *DNSServerDNSProvider) addresses() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	addresses := map[string]string{}
+	for _, name := range d.aRecords {
+		if address := d.records[name]; address != "" {
+			addresses[dns.Fqdn(strings.ToLower(name))] = address
+		}
+	}
+	return addresses
+}
+
+// DNSServer Authoritative dns server answering A, AAAA, TXT, SOA and NS queries of the zone from the addresses of the
+// DNSServerDNSProvider
+type DNSServer struct {
+	listen      string
+	zone        string
+	nameservers []string
+	hostmaster  string
+	ttl         uint32
+	txtRecords  map[string]string
+	provider    *DNSServerDNSProvider
+
+	mu       sync.Mutex
+	serial   uint32
+	snapshot map[string]string
+	servers  []*dns.Server
+}
+
+// NewDNSServer Returns an instance of DNSServer based on the passed configuration, answering from the addresses set on
+// the provider
+func NewDNSServer(config *DNSServerConfig, provider *DNSServerDNSProvider) *DNSServer {
+	zone := dns.Fqdn(strings.ToLower(config.Zone))
+
+	hostmaster := "hostmaster." + zone
+	if config.Hostmaster != "" {
+		hostmaster = dns.Fqdn(strings.Replace(config.Hostmaster, "@", ".", 1))
+	}
+
+	var nameservers []string
+	for _, ns := range config.Nameservers {
+		nameservers = append(nameservers, dns.Fqdn(strings.ToLower(ns)))
+	}
+
+	txtRecords := map[string]string{}
+	for name, txt := range config.TXTRecords {
+		txtRecords[dns.Fqdn(strings.ToLower(name))] = txt
+	}
+
+	return &DNSServer{
+		listen:      config.Listen,
+		zone:        zone,
+		nameservers: nameservers,
+		hostmaster:  hostmaster,
+		ttl:         config.TTL,
+		txtRecords:  txtRecords,
+		provider:    provider,
+		serial:      uint32(time.Now().Unix()),
+		snapshot:    map[string]string{},
+	}
+}
+
+// Start Listen for udp and tcp queries and answer them in the background
+func (d *DNSServer) Start() error {
+	pc, err := net.ListenPacket("udp", d.listen)
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", d.listen)
+	if err != nil {
+		pc.Close()
+		return err
+	}
+
+	d.mu.Lock()
+	d.servers = []*dns.Server{
+		{PacketConn: pc, Handler: d},
+		{Listener: l, Handler: d},
+	}
+	for _, server := range d.servers {
+		go func(server *dns.Server) {
+			if err := server.ActivateAndServe(); err != nil {
+				log.Error().Msgf("DNS server stopped: %s", err)
+			}
+		}(server)
+	}
+	d.mu.Unlock()
+
+	log.Info().Msgf("DNS server for zone %s listening on %s (udp) and %s (tcp)...", d.zone, pc.LocalAddr(), l.Addr())
+	return nil
+}
+
+// Addrs Returns the udp and tcp addresses the dns server listens on, empty if it was not started
+func (d *DNSServer) Addrs() (string, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.servers) == 0 {
+		return "", ""
+	}
+	return d.servers[0].PacketConn.LocalAddr().String(), d.servers[1].Listener.Addr().String()
+}
+
+// Shutdown Stop listening for queries
+func (d *DNSServer) Shutdown() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, server := range d.servers {
+		if err := server.Shutdown(); err != nil {
+			log.Error().Msgf("Could not shut down the dns server: %s", err)
+		}
+	}
+	d.servers = nil
+}
+
+// ServeDNS Answer a single query, implements dns.Handler
+func (d *DNSServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := d.answer(r)
+
+	// Responses exceeding the udp size of the client are truncated, so that it retries over tcp
+	if _, ok := w.LocalAddr().(*net.UDPAddr); ok {
+		size := dns.MinMsgSize
+		if opt := r.IsEdns0(); opt != nil {
+			size = int(opt.UDPSize())
+		}
+		m.Truncate(size)
+	}
+
+	if err := w.WriteMsg(m); err != nil {
+		log.Error().Msgf("Could not answer dns query from %s: %s", w.RemoteAddr(), err)
+	}
+}
+
+// answer Returns the response to the query
+func (d *DNSServer) answer(r *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if opt := r.IsEdns0(); opt != nil {
+		m.SetEdns0(dns.DefaultMsgSize, false)
+	}
+
+	if r.Opcode != dns.OpcodeQuery {
+		m.Rcode = dns.RcodeNotImplemented
+		return m
+	}
+	if len(r.Question) != 1 {
+		m.Rcode = dns.RcodeFormatError
+		return m
+	}
+
+	q := r.Question[0]
+	name := strings.ToLower(q.Name)
+	if !dns.IsSubDomain(d.zone, name) || q.Qclass != dns.ClassINET && q.Qclass != dns.ClassANY {
+		m.Rcode = dns.RcodeRefused
+		return m
+	}
+	m.Authoritative = true
+
+	addresses, serial := d.addresses()
+	hdr := func(rrType uint16) dns.RR_Header {
+		return dns.RR_Header{Name: q.Name, Rrtype: rrType, Class: dns.ClassINET, Ttl: d.ttl}
+	}
+	wants := func(rrType uint16) bool {
+		return q.Qtype == rrType || q.Qtype == dns.TypeANY
+	}
+
+	if ip := net.ParseIP(addresses[name]); ip != nil {
+		if ip.To4() != nil && wants(dns.TypeA) {
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr(dns.TypeA), A: ip.To4()})
+		} else if ip.To4() == nil && wants(dns.TypeAAAA) {
+			m.Answer = append(m.Answer, &dns.AAAA{Hdr: hdr(dns.TypeAAAA), AAAA: ip})
+		}
+	}
+	if txt, ok := d.txtRecords[name]; ok && wants(dns.TypeTXT) {
+		m.Answer = append(m.Answer, &dns.TXT{Hdr: hdr(dns.TypeTXT), Txt: []string{txt}})
+	}
+	if name == d.zone {
+		if wants(dns.TypeSOA) {
+			m.Answer = append(m.Answer, d.soa(serial))
+		}
+		if wants(dns.TypeNS) {
+			for _, ns := range d.nameservers {
+				m.Answer = append(m.Answer, &dns.NS{Hdr: hdr(dns.TypeNS), Ns: ns})
+				m.Extra = append(m.Extra, d.glue(ns, addresses)...)
+			}
+		}
+	}
+
+	if len(m.Answer) == 0 {
+		// NXDOMAIN for names without any records or records below them, NODATA otherwise
+		if !d.exists(name, addresses) {
+			m.Rcode = dns.RcodeNameError
+		}
+		m.Ns = append(m.Ns, d.soa(serial))
+	}
+
+	log.Debug().Msgf("Answering %s %s with %s and %d records", dns.TypeToString[q.Qtype], q.Name, dns.RcodeToString[m.Rcode], len(m.Answer))
+	return m
+}
+
+// addresses Returns the current addresses of the served names by lower case fully qualified name along with the serial
+// of the zone, which is increased whenever an address changed
+func (d *DNSServer) addresses() (map[string]string, uint32) {
+	addresses := d.provider.addresses()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	changed := len(addresses) != len(d.snapshot)
+	for name, address := range addresses {
+		changed = changed || d.snapshot[name] != address
+	}
+	if changed {
+		if now := uint32(time.Now().Unix()); now > d.serial {
+			d.serial = now
+		} else {
+			d.serial++
+		}
+		d.snapshot = addresses
+	}
+
+	return addresses, d.serial
+}
+
+// exists Returns true if the name or a name below it has records
+func (d *DNSServer) exists(name string, addresses map[string]string) bool {
+	names := []string{d.zone}
+	for n := range addresses {
+		names = append(names, n)
+	}
+	for n := range d.txtRecords {
+		names = append(names, n)
+	}
+
+	for _, n := range names {
+		if dns.IsSubDomain(name, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// soa Returns the SOA record of the zone
+func (d *DNSServer) soa(serial uint32) dns.RR {
+	primary := d.zone
+	if len(d.nameservers) > 0 {
+		primary = d.nameservers[0]
+	}
+
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: d.zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: d.ttl},
+		Ns:      primary,
+		Mbox:    d.hostmaster,
+		Serial:  serial,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  604800,
+		Minttl:  d.ttl,
+	}
+}
+
+// glue Returns the address records of a name server inside the zone
+func (d *DNSServer) glue(ns string, addresses map[string]string) []dns.RR {
+	ip := net.ParseIP(addresses[ns])
+	if ip == nil {
+		return nil
+	}
+
+	hdr := dns.RR_Header{Name: ns, Class: dns.ClassINET, Ttl: d.ttl}
+	if ip.To4() != nil {
+		hdr.Rrtype = dns.TypeA
+		return []dns.RR{&dns.A{Hdr: hdr, A: ip.To4()}}
+	}
+	hdr.Rrtype = dns.TypeAAAA
+	return []dns.RR{&dns.AAAA{Hdr: hdr, AAAA: ip}}
+}
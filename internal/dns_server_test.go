@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// newTestDNSServer Returns a started DNSServer for dyn.example.com on a random local port whose records are synchronized
+// to the address
+func newTestDNSServer(t *testing.T, address string) (*DNSServer, *DNSServerDNSProvider) {
+	t.Helper()
+	c := defaultConfig
+	c.DNSServerConfig.Enable = true
+	c.DNSServerConfig.Listen = "127.0.0.1:0"
+	c.DNSServerConfig.Zone = "dyn.example.com"
+	c.DNSServerConfig.Nameservers = StringList{"ns1.dyn.example.com", "ns.example.net"}
+	c.DNSServerConfig.TXTRecords = map[string]string{"_acme-challenge.home.dyn.example.com": "token"}
+	c.DNSServerConfig.ARecords = StringList{"home.dyn.example.com", "ns1.dyn.example.com"}
+
+	p := NewDNSServerDNSProvider(&c.DNSServerConfig, &c.StateStoreConfig)
+	s := NewSyncer(&c, NewStaticIPAddressProvider(&StaticIPAddressProviderConfig{Enable: true, Address: address}), p, false)
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDNSServer(&c.DNSServerConfig, p)
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Shutdown)
+
+	return d, p
+}
+
+// TestDNSServer tests that queries over udp and tcp are answered authoritatively from the synchronized records
+func TestDNSServer(t *testing.T) {
+	d, _ := newTestDNSServer(t, "192.0.2.1")
+	udp, tcp := d.Addrs()
+
+	tests := []struct {
+		name       string
+		qtype      uint16
+		rcode      int
+		answer     []string
+		authority  int
+		additional []string
+	}{
+		{"home.dyn.example.com.", dns.TypeA, dns.RcodeSuccess, []string{"home.dyn.example.com.\t60\tIN\tA\t192.0.2.1"}, 0, nil},
+		{"HOME.dyn.example.com.", dns.TypeA, dns.RcodeSuccess, []string{"HOME.dyn.example.com.\t60\tIN\tA\t192.0.2.1"}, 0, nil},
+		{"home.dyn.example.com.", dns.TypeAAAA, dns.RcodeSuccess, nil, 1, nil},
+		{"_acme-challenge.home.dyn.example.com.", dns.TypeTXT, dns.RcodeSuccess, []string{"_acme-challenge.home.dyn.example.com.\t60\tIN\tTXT\t\"token\""}, 0, nil},
+		{"dyn.example.com.", dns.TypeNS, dns.RcodeSuccess, []string{"dyn.example.com.\t60\tIN\tNS\tns1.dyn.example.com.", "dyn.example.com.\t60\tIN\tNS\tns.example.net."}, 0, []string{"ns1.dyn.example.com.\t60\tIN\tA\t192.0.2.1"}},
+		{"dyn.example.com.", dns.TypeA, dns.RcodeSuccess, nil, 1, nil},
+		{"missing.dyn.example.com.", dns.TypeA, dns.RcodeNameError, nil, 1, nil},
+		{"home.dyn.example.com.", dns.TypeSOA, dns.RcodeSuccess, nil, 1, nil},
+		{"example.com.", dns.TypeA, dns.RcodeRefused, nil, 0, nil},
+	}
+
+	for _, test := range tests {
+		for network, addr := range map[string]string{"udp": udp, "tcp": tcp} {
+			q := new(dns.Msg)
+			q.SetQuestion(test.name, test.qtype)
+			r, _, err := (&dns.Client{Net: network}).Exchange(q, addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query := network + " " + test.name + " " + dns.TypeToString[test.qtype]
+			if r.Rcode != test.rcode {
+				t.Errorf("got %s for %s, wanted %s", dns.RcodeToString[r.Rcode], query, dns.RcodeToString[test.rcode])
+			}
+			if r.Authoritative != (test.rcode != dns.RcodeRefused) {
+				t.Errorf("got authoritative %t for %s, wanted %t", r.Authoritative, query, !r.Authoritative)
+			}
+			if len(r.Answer) != len(test.answer) || len(r.Ns) != test.authority || len(r.Extra) != len(test.additional) {
+				t.Errorf("got %v, %v and %v for %s, wanted %v, %d records and %v", r.Answer, r.Ns, r.Extra, query, test.answer, test.authority, test.additional)
+				continue
+			}
+			for i, rr := range r.Answer {
+				if rr.String() != test.answer[i] {
+					t.Errorf("got %s for %s, wanted %s", rr, query, test.answer[i])
+				}
+			}
+			for i, rr := range r.Extra {
+				if rr.String() != test.additional[i] {
+					t.Errorf("got %s for %s, wanted %s", rr, query, test.additional[i])
+				}
+			}
+		}
+	}
+}
+
+// TestDNSServerUpdate tests that an update is answered immediately with a higher SOA serial
+func TestDNSServerUpdate(t *testing.T) {
+	d, p := newTestDNSServer(t, "2001:db8::1")
+	udp, _ := d.Addrs()
+
+	query := func(qtype uint16) *dns.Msg {
+		t.Helper()
+		q := new(dns.Msg)
+		q.SetQuestion("home.dyn.example.com.", qtype)
+		r, err := dns.Exchange(q, udp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	serial := func() uint32 {
+		t.Helper()
+		q := new(dns.Msg)
+		q.SetQuestion("dyn.example.com.", dns.TypeSOA)
+		r, err := dns.Exchange(q, udp)
+		if err != nil || len(r.Answer) != 1 {
+			t.Fatalf("got %v and %v, wanted a SOA record", r, err)
+		}
+		return r.Answer[0].(*dns.SOA).Serial
+	}
+
+	if r := query(dns.TypeAAAA); len(r.Answer) != 1 || r.Answer[0].(*dns.AAAA).AAAA.String() != "2001:db8::1" {
+		t.Errorf("got %v, wanted 2001:db8::1", r.Answer)
+	}
+	before := serial()
+
+	m := RecordAddressMapping{ID: "home.dyn.example.com.", ARecord: "home.dyn.example.com", IPAddress: "2001:db8::1"}
+	if err := p.SetARecordAddress(context.Background(), "2001:db8::2", m); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := query(dns.TypeAAAA); len(r.Answer) != 1 || r.Answer[0].(*dns.AAAA).AAAA.String() != "2001:db8::2" {
+		t.Errorf("got %v, wanted 2001:db8::2", r.Answer)
+	}
+	if after := serial(); after <= before {
+		t.Errorf("got serial %d, wanted more than %d", after, before)
+	}
+}
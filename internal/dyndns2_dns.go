@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// DynDNS2DNSProviderConfig Configuration for the dyndns2 protocol DNS Provider
+type DynDNS2DNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_DYNDNS2_PROVIDER_ENABLE" required:"false"`
+
+	// URL of the update endpoint, e.g. https://dynupdate.no-ip.com/nic/update
+	URL string `yaml:"url" envconfig:"DDNS_DYNDNS2_PROVIDER_URL" required:"false"`
+
+	// BasicAuth username
+	Username string `yaml:"username" envconfig:"DDNS_DYNDNS2_PROVIDER_USERNAME" required:"false"`
+
+	// BasicAuth password or update token
+	Password string `yaml:"password" envconfig:"DDNS_DYNDNS2_PROVIDER_PASSWORD" required:"false" secret:"true"`
+
+	// User agent sent with every request, services block generic user agents
+	UserAgent string `yaml:"userAgent" envconfig:"DDNS_DYNDNS2_PROVIDER_USER_AGENT" required:"false"`
+
+	// Host and port of the name server the current addresses are looked up from, the system resolver is used if empty
+	Resolver string `yaml:"resolver" envconfig:"DDNS_DYNDNS2_PROVIDER_RESOLVER" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_DYNDNS2_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultDynDNS2DNSProviderConfig = &DynDNS2DNSProviderConfig{
+	Enable:    false,
+	URL:       "https://members.dyndns.org/nic/update",
+	Username:  "",
+	Password:  "",
+	UserAgent: "ddns",
+	Resolver:  "",
+	ARecords:  nil,
+}
+
+// dynDNS2ServerErrorBackoff Time to wait before contacting the service again after a server error as required by the protocol
+const dynDNS2ServerErrorBackoff = 30 * time.Minute
+
+// DynDNS2Error An error return code of the dyndns2 protocol
+type DynDNS2Error struct {
+	// Return code, e.g. badauth, nohost or 911
+	Code string
+
+	// Hostname the return code refers to, empty if it refers to the whole request
+	Hostname string
+}
+
+func (e *DynDNS2Error) Error() string {
+	descriptions := map[string]string{
+		"badauth":  "the username and password are invalid",
+		"!donator": "the request uses a feature not available to the account",
+		"notfqdn":  "the hostname is not a fully qualified domain name",
+		"nohost":   "the hostname does not exist in the account",
+		"numhost":  "too many hostnames were specified",
+		"abuse":    "the hostname is blocked for update abuse",
+		"badagent": "the user agent was rejected",
+		"dnserr":   "the service has a dns error",
+		"911":      "the service has a server error",
+	}
+
+	description, ok := descriptions[e.Code]
+	if !ok {
+		description = "unknown return code"
+	}
+	if e.Hostname == "" {
+		return fmt.Sprintf("dyndns2 update failed with %s: %s", e.Code, description)
+	}
+	return fmt.Sprintf("dyndns2 update of %s failed with %s: %s", e.Hostname, e.Code, description)
+}
+
+// Fatal Returns true if the protocol forbids further updates until the configuration was changed
+func (e *DynDNS2Error) Fatal() bool {
+	switch e.Code {
+	case "dnserr", "911":
+		return false
+	}
+	return true
+}
+
+// DynDNS2DNSProvider DNS Provider speaking the dyndns2 protocol used by DynDNS, No-IP and compatible services
+type DynDNS2DNSProvider struct {
+	url       string
+	username  string
+	password  string
+	userAgent string
+	aRecords  []string
+	client    *http.Client
+	lookup    func(ctx context.Context, host string) ([]net.IP, error)
+
+	mu         sync.Mutex
+	current    map[string]string
+	blocked    error
+	retryAfter time.Time
+}
+
+// NewDynDNS2DNSProvider Returns an instance of DynDNS2DNSProvider based on the passed configuration
+func NewDynDNS2DNSProvider(config *DynDNS2DNSProviderConfig) *DynDNS2DNSProvider {
+	resolver := net.DefaultResolver
+	if config.Resolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, config.Resolver)
+			},
+		}
+	}
+
+	return &DynDNS2DNSProvider{
+		url:       config.URL,
+		username:  config.Username,
+		password:  config.Password,
+		userAgent: config.UserAgent,
+		aRecords:  config.ARecords,
+		client:    &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		lookup: func(ctx context.Context, host string) ([]net.IP, error) {
+			return resolver.LookupIP(ctx, "ip4", host)
+		},
+		current: map[string]string{},
+	}
+}
+
+// GetARecordAddresses Return the addresses the hostnames were last set to, hostnames not updated by this instance yet are looked up
+// via DNS as the protocol has no way to read records
+func (p *DynDNS2DNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range p.aRecords {
+		p.mu.Lock()
+		address, ok := p.current[name]
+		p.mu.Unlock()
+
+		if !ok {
+			ips, err := p.lookup(ctx, name)
+			var dnsErr *net.DNSError
+			if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+				return nil, fmt.Errorf("could not look up %s: %w", name, err)
+			}
+			if len(ips) > 0 {
+				address = ips[0].String()
+			}
+		}
+
+		ms = append(ms, RecordAddressMapping{ID: name, ARecord: name, IPAddress: address})
+	}
+
+	p.mu.Lock()
+	for _, m := range ms {
+		p.current[m.ARecord] = m.IPAddress
+	}
+	p.mu.Unlock()
+
+	return ms, nil
+}
+
+// SetARecordAddress Set the hostname to the ip address, see SetARecordAddresses
+func (p *DynDNS2DNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	return p.SetARecordAddresses(ctx, ipAddress, []RecordAddressMapping{m})[0]
+}
+
+// SetARecordAddresses Set all provided hostnames to the ip address in a single request and map the return code of every
+// hostname back to its A record. After a fatal return code no further requests are sent, after a server error none for
+// 30 minutes.
+func (p *DynDNS2DNSProvider) SetARecordAddresses(ctx context.Context, ipAddress string, ms []RecordAddressMapping) []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	errs := make([]error, len(ms))
+	fail := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	if p.blocked != nil {
+		return fail(fmt.Errorf("not sending updates until the configuration is changed: %w", p.blocked))
+	}
+	if time.Now().Before(p.retryAfter) {
+		return fail(fmt.Errorf("not sending updates before %s after a server error", p.retryAfter.Format(time.RFC3339)))
+	}
+
+	var hostnames []string
+	for _, m := range ms {
+		hostnames = append(hostnames, m.ARecord)
+	}
+	log.Info().Msgf("Setting A records %s to %s", strings.Join(hostnames, ","), ipAddress)
+
+	codes, err := p.update(ctx, hostnames, ipAddress)
+	if err != nil {
+		return fail(err)
+	}
+
+	for i, m := range ms {
+		// Services answer with one line per hostname, a single line refers to the whole request
+		code, hostname := codes[0], ""
+		if len(codes) == len(ms) {
+			code, hostname = codes[i], m.ARecord
+		}
+
+		switch code {
+		case "good", "nochg":
+			p.current[m.ARecord] = ipAddress
+			continue
+		}
+
+		e := &DynDNS2Error{Code: code, Hostname: hostname}
+		if e.Fatal() {
+			p.blocked = e
+		} else {
+			p.retryAfter = time.Now().Add(dynDNS2ServerErrorBackoff)
+		}
+		errs[i] = e
+	}
+
+	return errs
+}
+
+// update Send the update request and return the return code of every line of the response
+func (p *DynDNS2DNSProvider) update(ctx context.Context, hostnames []string, ipAddress string) ([]string, error) {
+	u, err := url.Parse(p.url)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("hostname", strings.Join(hostnames, ","))
+	q.Set("myip", ipAddress)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(p.username, p.password)
+	req.Header.Set("User-Agent", p.userAgent)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	// Some services answer badauth with 401 and a body, others without
+	if res.StatusCode == http.StatusUnauthorized {
+		return []string{"badauth"}, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status code from %s was %s, not 200", u.Host, res.Status)
+	}
+
+	var codes []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			codes = append(codes, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, errors.New("empty response from dyndns2 service")
+	}
+
+	return codes, nil
+}
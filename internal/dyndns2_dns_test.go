@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDynDNS2Server An in-process dyndns2 service answering every hostname of a request with the configured return code,
+// unless a return code is configured for the hostname
+type fakeDynDNS2Server struct {
+	mu       sync.Mutex
+	code     string
+	codes    map[string]string
+	requests []string
+}
+
+func (f *fakeDynDNS2Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.URL.RawQuery)
+	if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+		_, _ = fmt.Fprintln(w, "badauth")
+		return
+	}
+	if r.URL.Path != "/nic/update" || r.UserAgent() != "ddns-test/1.0" {
+		_, _ = fmt.Fprintln(w, "badagent")
+		return
+	}
+
+	for _, hostname := range strings.Split(r.URL.Query().Get("hostname"), ",") {
+		code := f.code
+		if c, ok := f.codes[hostname]; ok {
+			code = c
+		}
+		if code == "good" || code == "nochg" {
+			_, _ = fmt.Fprintf(w, "%s %s\n", code, r.URL.Query().Get("myip"))
+		} else {
+			_, _ = fmt.Fprintln(w, code)
+		}
+	}
+}
+
+func newTestDynDNS2DNSProvider(t *testing.T, code string) (*fakeDynDNS2Server, *DynDNS2DNSProvider) {
+	t.Helper()
+	f := &fakeDynDNS2Server{code: code}
+	s := httptest.NewServer(f)
+	t.Cleanup(s.Close)
+
+	c := *defaultDynDNS2DNSProviderConfig
+	c.URL = s.URL + "/nic/update"
+	c.Username = "user"
+	c.Password = "secret"
+	c.UserAgent = "ddns-test/1.0"
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	return f, NewDynDNS2DNSProvider(&c)
+}
+
+// TestDynDNS2DNSProvider tests that current addresses are looked up via DNS and the provided hostnames are set in one
+// request
+func TestDynDNS2DNSProvider(t *testing.T) {
+	_, resolver := newFakeAuthoritativeServer(t, "udp")
+	f, p := newTestDynDNS2DNSProvider(t, "good")
+	c := *defaultDynDNS2DNSProviderConfig
+	c.Resolver = resolver
+	p.lookup = NewDynDNS2DNSProvider(&c).lookup
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, err := range p.SetARecordAddresses(context.Background(), "10.0.0.1", ms[1:]) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(f.requests) != 1 {
+		t.Fatalf("got %d requests, wanted 1", len(f.requests))
+	}
+	if wanted := "hostname=www.example.com%2Cnew.example.com&myip=10.0.0.1"; f.requests[0] != wanted {
+		t.Errorf("got %s, wanted %s", f.requests[0], wanted)
+	}
+
+	// Set addresses are remembered instead of relying on possibly cached DNS answers
+	ms, err = p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if m.IPAddress != "10.0.0.1" {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, "10.0.0.1")
+		}
+	}
+}
+
+// TestDynDNS2DNSProviderReturnCodes tests that return codes are mapped to typed errors and fatal ones stop further requests
+func TestDynDNS2DNSProviderReturnCodes(t *testing.T) {
+	for _, code := range []string{"badauth", "nohost", "abuse", "911"} {
+		t.Run(code, func(t *testing.T) {
+			f, p := newTestDynDNS2DNSProvider(t, code)
+			m := RecordAddressMapping{ID: "example.com", ARecord: "example.com"}
+
+			err := p.SetARecordAddress(context.Background(), "10.0.0.1", m)
+			var e *DynDNS2Error
+			if !errors.As(err, &e) {
+				t.Fatalf("got %v, wanted a DynDNS2Error", err)
+			}
+			if e.Code != code {
+				t.Errorf("got %s, wanted %s", e.Code, code)
+			}
+			if e.Fatal() == (code == "911") {
+				t.Errorf("got fatal %t for %s, wanted %t", e.Fatal(), code, code != "911")
+			}
+
+			// Neither fatal errors nor server errors within 30 minutes may be retried
+			if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err == nil {
+				t.Errorf("got no error for the retry, wanted one")
+			}
+			if len(f.requests) != 1 {
+				t.Errorf("got %d requests, wanted 1", len(f.requests))
+			}
+
+			if code == "911" {
+				p.retryAfter = time.Now().Add(-time.Second)
+				_ = p.SetARecordAddress(context.Background(), "10.0.0.1", m)
+				if len(f.requests) != 2 {
+					t.Errorf("got %d requests after the backoff, wanted 2", len(f.requests))
+				}
+			}
+		})
+	}
+}
+
+// TestDynDNS2DNSProviderBadAuth tests that a rejected password is reported as badauth without further requests
+func TestDynDNS2DNSProviderBadAuth(t *testing.T) {
+	f, p := newTestDynDNS2DNSProvider(t, "good")
+	p.password = "wrong"
+	m := RecordAddressMapping{ID: "example.com", ARecord: "example.com"}
+
+	err := p.SetARecordAddress(context.Background(), "10.0.0.1", m)
+	var e *DynDNS2Error
+	if !errors.As(err, &e) || e.Code != "badauth" {
+		t.Fatalf("got %v, wanted badauth", err)
+	}
+	if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); !errors.As(err, &e) {
+		t.Errorf("got %v for the retry, wanted the blocking DynDNS2Error", err)
+	}
+	if len(f.requests) != 1 {
+		t.Errorf("got %d requests, wanted 1", len(f.requests))
+	}
+}
+
+// TestDynDNS2DNSProviderPerHostReturnCodes tests that the return code of every hostname is mapped back to its A record
+func TestDynDNS2DNSProviderPerHostReturnCodes(t *testing.T) {
+	f, p := newTestDynDNS2DNSProvider(t, "good")
+	f.codes = map[string]string{"www.example.com": "nohost"}
+	ms := []RecordAddressMapping{{ARecord: "example.com"}, {ARecord: "www.example.com"}, {ARecord: "new.example.com"}}
+
+	errs := p.SetARecordAddresses(context.Background(), "10.0.0.1", ms)
+	var e *DynDNS2Error
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("got %v, wanted example.com and new.example.com to be set", errs)
+	}
+	if !errors.As(errs[1], &e) || e.Code != "nohost" || e.Hostname != "www.example.com" {
+		t.Errorf("got %v, wanted nohost for www.example.com", errs[1])
+	}
+	if p.current["example.com"] != "10.0.0.1" || p.current["www.example.com"] == "10.0.0.1" {
+		t.Errorf("got %v, wanted only the successful hostnames to be remembered", p.current)
+	}
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NetlinkEventsConfig Config section governing synchronizations triggered by address changes
+type NetlinkEventsConfig struct {
+	// Switch to enable or disable synchronizations triggered by netlink address and route change events
+	Enable bool `yaml:"enable" envconfig:"DDNS_NETLINK_ENABLE" required:"false"`
+
+	// Go duration without further events to wait for before triggering a synchronization
+	Debounce time.Duration `yaml:"debounce" envconfig:"DDNS_NETLINK_DEBOUNCE" required:"false"`
+}
+
+var defaultNetlinkEventsConfig = &NetlinkEventsConfig{
+	Enable:   false,
+	Debounce: 2 * time.Second,
+}
+
+// WatchAddressChanges Returns a channel that receives once address or route changes settled for the debounce duration
+func WatchAddressChanges(config *NetlinkEventsConfig) (<-chan struct{}, error) {
+	events, err := subscribeAddressChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Listening for netlink address and route changes with a debounce of %s", config.Debounce)
+	return debounce(events, config.Debounce), nil
+}
+
+// debounce Forwards a single event once no further event was received on in for the duration d
+func debounce(in <-chan struct{}, d time.Duration) <-chan struct{} {
+	out := make(chan struct{}, 1)
+
+	go func() {
+		defer close(out)
+
+		var timer <-chan time.Time
+		for {
+			select {
+			case _, ok := <-in:
+				if !ok {
+					return
+				}
+				timer = time.After(d)
+			case <-timer:
+				timer = nil
+				select {
+				case out <- struct{}{}:
+				default:
+					// A trigger is already pending
+				}
+			}
+		}
+	}()
+
+	return out
+}
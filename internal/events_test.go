@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDebounce tests that a burst of events results in a single trigger after the events settled
+func TestDebounce(t *testing.T) {
+	in := make(chan struct{})
+	out := debounce(in, 50*time.Millisecond)
+
+	for n := 0; n < 5; n++ {
+		in <- struct{}{}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case <-out:
+	case <-time.After(time.Second):
+		t.Fatal("expected a trigger after the events settled")
+	}
+
+	select {
+	case <-out:
+		t.Error("expected a single trigger for a burst of events")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(in)
+	if _, ok := <-out; ok {
+		t.Error("expected output channel to be closed")
+	}
+}
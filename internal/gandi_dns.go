@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// GandiDNSProviderConfig Configuration for the Gandi LiveDNS DNS Provider
+type GandiDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_GANDI_PROVIDER_ENABLE" required:"false"`
+
+	// Gandi personal access token with the "Manage domain name technical configurations" permission
+	Token string `yaml:"token" envconfig:"DDNS_GANDI_PROVIDER_TOKEN" required:"false" secret:"true"`
+
+	// Domain the A records belong to
+	Domain string `yaml:"domain" envconfig:"DDNS_GANDI_PROVIDER_DOMAIN" required:"false"`
+
+	// Url of the LiveDNS API
+	BaseURL string `yaml:"baseURL" envconfig:"DDNS_GANDI_PROVIDER_BASE_URL" required:"false"`
+
+	// TTL of created A records in seconds, existing record sets keep their TTL
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_GANDI_PROVIDER_TTL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_GANDI_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultGandiDNSProviderConfig = &GandiDNSProviderConfig{
+	Enable:   false,
+	Token:    "",
+	Domain:   "",
+	BaseURL:  "https://api.gandi.net/v5/livedns",
+	TTL:      300,
+	ARecords: nil,
+}
+
+type gandiRRset struct {
+	TTL    int64    `json:"rrset_ttl"`
+	Values []string `json:"rrset_values"`
+}
+
+// GandiDNSProvider Gandi LiveDNS DNS Provider
+type GandiDNSProvider struct {
+	token    string
+	domain   string
+	baseURL  string
+	ttl      int64
+	aRecords []string
+	client   *http.Client
+}
+
+// NewGandiDNSProvider Returns an instance of GandiDNSProvider based on the passed configuration
+func NewGandiDNSProvider(config *GandiDNSProviderConfig) *GandiDNSProvider {
+	return &GandiDNSProvider{
+		token:    config.Token,
+		domain:   strings.TrimSuffix(config.Domain, "."),
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		ttl:      config.TTL,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the ip address is empty if a name has no A record set yet
+func (g *GandiDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range g.aRecords {
+		rrset, err := g.getRRset(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := RecordAddressMapping{ID: relativeRecordName(name, g.domain), ARecord: name}
+		if rrset != nil && len(rrset.Values) > 0 {
+			m.IPAddress = rrset.Values[0]
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Replace the A record set of the name with the provided ip address, the record set is created if it does
+// not exist yet
+func (g *GandiDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	rrset, err := g.getRRset(ctx, m.ARecord)
+	if err != nil {
+		return err
+	}
+
+	ttl := g.ttl
+	if rrset != nil {
+		ttl = rrset.TTL
+	}
+
+	if _, err := g.do(ctx, "PUT", g.rrsetPath(m.ARecord), &gandiRRset{TTL: ttl, Values: []string{ipAddress}}, nil); err != nil {
+		return fmt.Errorf("could not put A record set %s: %w", m.ARecord, err)
+	}
+
+	return nil
+}
+
+// getRRset Returns the A record set of the name or nil if it does not exist
+func (g *GandiDNSProvider) getRRset(ctx context.Context, name string) (*gandiRRset, error) {
+	var rrset gandiRRset
+	status, err := g.do(ctx, "GET", g.rrsetPath(name), nil, &rrset)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get A record set %s: %w", name, err)
+	}
+	return &rrset, nil
+}
+
+func (g *GandiDNSProvider) rrsetPath(name string) string {
+	return "/domains/" + url.PathEscape(g.domain) + "/records/" + url.PathEscape(relativeRecordName(name, g.domain)) + "/A"
+}
+
+// do Send a request with the json encoded payload to the API and decode the json response into v if not nil,
+// the status code is returned alongside errors
+func (g *GandiDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", g.token))
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	// Record sets are created with 201
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Message != "" {
+			return res.StatusCode, fmt.Errorf("response status code from %s was %s: %s", path, res.Status, e.Message)
+		}
+		return res.StatusCode, fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGandi An in-process stand-in for the Gandi LiveDNS record API of a single domain
+type fakeGandi struct {
+	mu     sync.Mutex
+	rrsets map[string]gandiRRset
+}
+
+func (f *fakeGandi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer pat" {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "Access was denied to this resource."})
+		return
+	}
+
+	const recordsPath = "/domains/example.com/records/"
+	name, ok := strings.CutPrefix(r.URL.Path, recordsPath)
+	name, isA := strings.CutSuffix(name, "/A")
+	if !ok || !isA {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		rrset, ok := f.rrsets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"message": "Can't find the DNS record"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(rrset)
+	case "PUT":
+		var rrset gandiRRset
+		_ = json.NewDecoder(r.Body).Decode(&rrset)
+		f.rrsets[name] = rrset
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "DNS Record Created"})
+	}
+}
+
+// TestGandiDNSProvider tests that record sets are read by name and replaced or created with PUT
+func TestGandiDNSProvider(t *testing.T) {
+	f := &fakeGandi{rrsets: map[string]gandiRRset{
+		"@":   {TTL: 10800, Values: []string{"10.0.0.1"}},
+		"www": {TTL: 300, Values: []string{"10.0.0.2"}},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultGandiDNSProviderConfig
+	c.Token = "pat"
+	c.Domain = "example.com"
+	c.BaseURL = s.URL
+	c.TTL = 1800
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewGandiDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.3", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantTTLs := map[string]int64{"@": 10800, "www": 300, "new": 1800}
+	for name, ttl := range wantTTLs {
+		rrset := f.rrsets[name]
+		if strings.Join(rrset.Values, ",") != "10.0.0.3" || rrset.TTL != ttl {
+			t.Errorf("got %v with ttl %d for %s, wanted 10.0.0.3 with ttl %d", rrset.Values, rrset.TTL, name, ttl)
+		}
+	}
+
+	p.token = "wrong"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil || !strings.Contains(err.Error(), "Access was denied") {
+		t.Errorf("got %v, wanted the error of the API", err)
+	}
+}
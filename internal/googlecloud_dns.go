@@ -0,0 +1,334 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// GoogleCloudDNSProviderConfig Configuration for the Google Cloud DNS Provider
+type GoogleCloudDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_ENABLE" required:"false"`
+
+	// Service account JSON key, usually referenced as file:/path/to/key.json
+	Credentials string `yaml:"credentials" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_CREDENTIALS" required:"false" secret:"true"`
+
+	// Project of the managed zone, the project of the service account is used if empty
+	Project string `yaml:"project" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_PROJECT" required:"false"`
+
+	// Name of the managed zone the A records belong to
+	ManagedZone string `yaml:"managedZone" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_MANAGED_ZONE" required:"false"`
+
+	// TTL of created A records in seconds, existing record sets keep their TTL
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_TTL" required:"false"`
+
+	// Url of the Cloud DNS API
+	APIURL string `yaml:"apiURL" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_API_URL" required:"false"`
+
+	// Url of the OAuth 2.0 token endpoint, the token_uri of the service account is used if empty
+	TokenURL string `yaml:"tokenURL" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_TOKEN_URL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_GOOGLECLOUD_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultGoogleCloudDNSProviderConfig = &GoogleCloudDNSProviderConfig{
+	Enable:      false,
+	Credentials: "",
+	Project:     "",
+	ManagedZone: "",
+	TTL:         300,
+	APIURL:      "https://dns.googleapis.com/dns/v1",
+	TokenURL:    "",
+	ARecords:    nil,
+}
+
+// googleCloudDNSScope OAuth 2.0 scope granting read and write access to Cloud DNS
+const googleCloudDNSScope = "https://www.googleapis.com/auth/ndev.clouddns.readwrite"
+
+// googleServiceAccountKey The fields of a service account JSON key required to sign token requests
+type googleServiceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+type googleCloudRRset struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int64    `json:"ttl"`
+	RRDatas []string `json:"rrdatas"`
+}
+
+type googleCloudListRRsetsResponse struct {
+	RRsets []googleCloudRRset `json:"rrsets"`
+}
+
+type googleCloudChange struct {
+	ID        string             `json:"id,omitempty"`
+	Status    string             `json:"status,omitempty"`
+	Additions []googleCloudRRset `json:"additions,omitempty"`
+	Deletions []googleCloudRRset `json:"deletions,omitempty"`
+}
+
+// parseGoogleServiceAccountKey Parse the service account JSON key and its RSA private key
+func parseGoogleServiceAccountKey(credentials string) (*googleServiceAccountKey, *rsa.PrivateKey, error) {
+	var key googleServiceAccountKey
+	if err := json.Unmarshal([]byte(credentials), &key); err != nil {
+		return nil, nil, errors.New("is not a service account JSON key")
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" {
+		return nil, nil, errors.New("is not a service account JSON key")
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, nil, errors.New("has no PEM encoded private_key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, nil, errors.New("has an unreadable private_key")
+		}
+	}
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("private_key is not an RSA key")
+	}
+
+	return &key, privateKey, nil
+}
+
+// GoogleCloudDNSProvider Google Cloud DNS Provider
+type GoogleCloudDNSProvider struct {
+	project     string
+	managedZone string
+	ttl         int64
+	apiURL      string
+	aRecords    []string
+	tokens      *oauthTokenSource
+	client      *http.Client
+	err         error
+}
+
+// NewGoogleCloudDNSProvider Returns an instance of GoogleCloudDNSProvider based on the passed configuration
+func NewGoogleCloudDNSProvider(config *GoogleCloudDNSProviderConfig) *GoogleCloudDNSProvider {
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	p := &GoogleCloudDNSProvider{
+		project:     config.Project,
+		managedZone: config.ManagedZone,
+		ttl:         config.TTL,
+		apiURL:      strings.TrimSuffix(config.APIURL, "/"),
+		aRecords:    config.ARecords,
+		client:      client,
+	}
+
+	key, privateKey, err := parseGoogleServiceAccountKey(config.Credentials)
+	if err != nil {
+		// Reported on every request, the config is validated before the provider is created
+		p.err = fmt.Errorf("credentials %w", err)
+		return p
+	}
+	if p.project == "" {
+		p.project = key.ProjectID
+	}
+
+	tokenURL := config.TokenURL
+	if tokenURL == "" {
+		tokenURL = key.TokenURI
+	}
+	if tokenURL == "" {
+		tokenURL = "https://oauth2.googleapis.com/token"
+	}
+
+	p.tokens = &oauthTokenSource{
+		client:   client,
+		tokenURL: tokenURL,
+		form: func() (url.Values, error) {
+			assertion, err := googleJWTAssertion(key, privateKey, tokenURL, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			return url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			}, nil
+		},
+	}
+
+	return p
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the ip address is empty if a name has no A record set yet
+func (p *GoogleCloudDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range p.aRecords {
+		rrset, err := p.getRRset(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := RecordAddressMapping{ID: dns.Fqdn(name), ARecord: name}
+		if rrset != nil && len(rrset.RRDatas) > 0 {
+			m.IPAddress = rrset.RRDatas[0]
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Replace the A record set of the name with one holding the provided ip address in a single change
+func (p *GoogleCloudDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	// Deletions have to match the current record set exactly
+	current, err := p.getRRset(ctx, m.ARecord)
+	if err != nil {
+		return err
+	}
+
+	change := googleCloudChange{Additions: []googleCloudRRset{{Name: dns.Fqdn(m.ARecord), Type: "A", TTL: p.ttl, RRDatas: []string{ipAddress}}}}
+	if current != nil {
+		change.Additions[0].TTL = current.TTL
+		change.Deletions = []googleCloudRRset{*current}
+	}
+
+	var res googleCloudChange
+	if err := p.do(ctx, "POST", p.zonePath()+"/changes", change, &res); err != nil {
+		return fmt.Errorf("could not change A record set %s: %w", m.ARecord, err)
+	}
+	log.Debug().Msgf("Created change %s with status %s", res.ID, res.Status)
+
+	return nil
+}
+
+// getRRset Returns the A record set of the name or nil if it does not exist
+func (p *GoogleCloudDNSProvider) getRRset(ctx context.Context, name string) (*googleCloudRRset, error) {
+	q := url.Values{}
+	q.Set("name", dns.Fqdn(name))
+	q.Set("type", "A")
+
+	var r googleCloudListRRsetsResponse
+	if err := p.do(ctx, "GET", p.zonePath()+"/rrsets?"+q.Encode(), nil, &r); err != nil {
+		return nil, fmt.Errorf("could not list record sets of %s: %w", name, err)
+	}
+
+	for i, rrset := range r.RRsets {
+		if rrset.Type == "A" && strings.EqualFold(rrset.Name, dns.Fqdn(name)) {
+			return &r.RRsets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *GoogleCloudDNSProvider) zonePath() string {
+	return "/projects/" + url.PathEscape(p.project) + "/managedZones/" + url.PathEscape(p.managedZone)
+}
+
+// do Send an authorized request with the json encoded payload to the API and decode the json response into v
+func (p *GoogleCloudDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	token, err := p.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("could not obtain an access token: %w", err)
+	}
+
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Error.Message != "" {
+			return fmt.Errorf("response status code from %s was %s: %s", path, res.Status, e.Error.Message)
+		}
+		return fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// googleJWTAssertion Returns the RS256 signed JWT the service account exchanges for an access token
+func googleJWTAssertion(key *googleServiceAccountKey, privateKey *rsa.PrivateKey, audience string, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": key.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   key.ClientEmail,
+		"scope": googleCloudDNSScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
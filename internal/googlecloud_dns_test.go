@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGoogleCloud An in-process stand-in for the Google token endpoint and the Cloud DNS record set and change APIs
+type fakeGoogleCloud struct {
+	mu            sync.Mutex
+	publicKey     *rsa.PublicKey
+	tokenRequests int
+	rrsets        map[string]googleCloudRRset
+	changes       []googleCloudChange
+}
+
+func (f *fakeGoogleCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		f.tokenRequests++
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || !f.verify(r.FormValue("assertion")) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Invalid JWT Signature."})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.test", "expires_in": 3600, "token_type": "Bearer"})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer ya29.test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const zonePath = "/dns/v1/projects/my-project/managedZones/example-zone"
+	switch {
+	case r.Method == "GET" && r.URL.Path == zonePath+"/rrsets":
+		res := googleCloudListRRsetsResponse{RRsets: []googleCloudRRset{}}
+		if rrset, ok := f.rrsets[r.URL.Query().Get("name")]; ok && r.URL.Query().Get("type") == "A" {
+			res.RRsets = append(res.RRsets, rrset)
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	case r.Method == "POST" && r.URL.Path == zonePath+"/changes":
+		var change googleCloudChange
+		_ = json.NewDecoder(r.Body).Decode(&change)
+		for _, d := range change.Deletions {
+			current, ok := f.rrsets[d.Name]
+			if !ok || current.TTL != d.TTL || strings.Join(current.RRDatas, ",") != strings.Join(d.RRDatas, ",") {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"message": "conditionNotMet"}})
+				return
+			}
+			delete(f.rrsets, d.Name)
+		}
+		for _, a := range change.Additions {
+			if _, ok := f.rrsets[a.Name]; ok {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.rrsets[a.Name] = a
+		}
+		f.changes = append(f.changes, change)
+		change.ID = "1"
+		change.Status = "pending"
+		_ = json.NewEncoder(w).Encode(change)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// verify Returns true if the JWT is signed by the service account and has the expected claims
+func (f *fakeGoogleCloud) verify(assertion string) bool {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(f.publicKey, crypto.SHA256, hash[:], signature) != nil {
+		return false
+	}
+
+	b, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	_ = json.Unmarshal(b, &claims)
+	return claims["iss"] == "ddns@my-project.iam.gserviceaccount.com" && claims["scope"] == googleCloudDNSScope
+}
+
+// newTestGoogleServiceAccountKey Returns a service account JSON key with a freshly generated RSA key
+func newTestGoogleServiceAccountKey(t *testing.T) (string, *rsa.PublicKey) {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := json.Marshal(googleServiceAccountKey{
+		Type:         "service_account",
+		ProjectID:    "my-project",
+		PrivateKeyID: "abc123",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  "ddns@my-project.iam.gserviceaccount.com",
+		TokenURI:     "https://oauth2.googleapis.com/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(key), &privateKey.PublicKey
+}
+
+// TestGoogleCloudDNSProvider tests that tokens are obtained with a signed JWT and record sets are replaced by change sets
+func TestGoogleCloudDNSProvider(t *testing.T) {
+	credentials, publicKey := newTestGoogleServiceAccountKey(t)
+	f := &fakeGoogleCloud{publicKey: publicKey, rrsets: map[string]googleCloudRRset{
+		"example.com.":     {Name: "example.com.", Type: "A", TTL: 60, RRDatas: []string{"10.0.0.1"}},
+		"www.example.com.": {Name: "www.example.com.", Type: "A", TTL: 3600, RRDatas: []string{"10.0.0.2", "10.0.0.3"}},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultGoogleCloudDNSProviderConfig
+	c.Credentials = credentials
+	c.ManagedZone = "example-zone"
+	c.APIURL = s.URL + "/dns/v1"
+	c.TokenURL = s.URL + "/token"
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewGoogleCloudDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, m := range ms[1:] {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if www := f.rrsets["www.example.com."]; strings.Join(www.RRDatas, ",") != "10.0.0.1" || www.TTL != 3600 {
+		t.Errorf("got %v with ttl %d, wanted 10.0.0.1 with ttl 3600", www.RRDatas, www.TTL)
+	}
+	if created := f.rrsets["new.example.com."]; strings.Join(created.RRDatas, ",") != "10.0.0.1" || created.TTL != 300 {
+		t.Errorf("got %v with ttl %d, wanted 10.0.0.1 with ttl 300", created.RRDatas, created.TTL)
+	}
+	if len(f.changes) != 2 || len(f.changes[0].Deletions) != 1 || len(f.changes[1].Deletions) != 0 {
+		t.Errorf("got %v, wanted a replacing and a creating change", f.changes)
+	}
+	if f.tokenRequests != 1 {
+		t.Errorf("got %d token requests, wanted 1", f.tokenRequests)
+	}
+}
+
+// TestGoogleCloudDNSProviderInvalidKey tests that a token signed with another key is rejected
+func TestGoogleCloudDNSProviderInvalidKey(t *testing.T) {
+	credentials, _ := newTestGoogleServiceAccountKey(t)
+	_, otherPublicKey := newTestGoogleServiceAccountKey(t)
+	s := httptest.NewServer(&fakeGoogleCloud{publicKey: otherPublicKey})
+	defer s.Close()
+
+	c := *defaultGoogleCloudDNSProviderConfig
+	c.Credentials = credentials
+	c.ManagedZone = "example-zone"
+	c.APIURL = s.URL + "/dns/v1"
+	c.TokenURL = s.URL + "/token"
+	c.ARecords = StringList{"example.com"}
+
+	_, err := NewGoogleCloudDNSProvider(&c).GetARecordAddresses(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant: Invalid JWT Signature.") {
+		t.Errorf("got %v, wanted invalid_grant", err)
+	}
+
+	c.Credentials = `{"type":"authorized_user"}`
+	_, err = NewGoogleCloudDNSProvider(&c).GetARecordAddresses(context.Background())
+	if err == nil || !strings.HasSuffix(err.Error(), "credentials is not a service account JSON key") {
+		t.Errorf("got %v, wanted an invalid credentials error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// HealthConfig Config section governing the liveness and readiness endpoints
+type HealthConfig struct {
+	// Go duration a single synchronization may take before the process is considered wedged
+	LivenessTimeout time.Duration `yaml:"livenessTimeout" envconfig:"DDNS_HEALTH_LIVENESS_TIMEOUT" required:"false"`
+
+	// Go duration within which the last successful synchronization has to lie for the process to be ready
+	ReadinessWindow time.Duration `yaml:"readinessWindow" envconfig:"DDNS_HEALTH_READINESS_WINDOW" required:"false"`
+}
+
+var defaultHealthConfig = &HealthConfig{
+	LivenessTimeout: 5 * time.Minute,
+	ReadinessWindow: 10 * time.Minute,
+}
+
+// ProviderHealth The outcome of the most recent calls to a provider
+type ProviderHealth struct {
+	Name        string    `json:"name"`
+	Healthy     bool      `json:"healthy"`
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// record Register the outcome of a call to the provider
+func (h *ProviderHealth) record(err error) {
+	if err != nil {
+		h.LastErrorAt = time.Now()
+		h.LastError = err.Error()
+	} else {
+		h.LastSuccess = time.Now()
+	}
+	h.Healthy = h.LastErrorAt.IsZero() || h.LastSuccess.After(h.LastErrorAt)
+}
+
+// HealthCheck The result of a single check of the liveness or readiness endpoint
+type HealthCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Liveness The body returned by the liveness endpoint
+type Liveness struct {
+	OK            bool      `json:"ok"`
+	Busy          bool      `json:"busy"`
+	LastIteration time.Time `json:"lastIteration,omitempty"`
+	NextRun       time.Time `json:"nextRun,omitempty"`
+}
+
+// Readiness The body returned by the readiness endpoint
+type Readiness struct {
+	OK          bool                      `json:"ok"`
+	Paused      bool                      `json:"paused"`
+	LastSuccess time.Time                 `json:"lastSuccess,omitempty"`
+	Checks      map[string]HealthCheck    `json:"checks"`
+	Providers   map[string]ProviderHealth `json:"providers"`
+
+	// Informational only, the current config is kept if a reload fails
+	ConfigReloadError string `json:"configReloadError,omitempty"`
+}
+
+// RegisterHealth Registers the liveness and readiness handlers for the Scheduler on the router
+func RegisterHealth(router *httprouter.Router, s *Scheduler, config *HealthConfig) {
+	router.GET("/healthz", LivenessHandler(s, config))
+	router.GET("/readyz", ReadinessHandler(s, config))
+}
+
+// LivenessHandler Return a httprouter.Handle function that fails if the current synchronization exceeds the liveness timeout
+func LivenessHandler(s *Scheduler, config *HealthConfig) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		busySince, lastIteration, nextRun := s.iterations()
+		l := Liveness{
+			OK:            busySince.IsZero() || time.Since(busySince) < config.LivenessTimeout,
+			Busy:          !busySince.IsZero(),
+			LastIteration: lastIteration,
+			NextRun:       nextRun,
+		}
+
+		status := http.StatusOK
+		if !l.OK {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, l)
+	}
+}
+
+// ReadinessHandler Return a httprouter.Handle function that fails unless the last successful synchronization lies within the readiness window
+func ReadinessHandler(s *Scheduler, config *HealthConfig) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		syncer := s.Syncer()
+		lastSuccess := syncer.LastSuccess()
+		ipAddressProvider, dnsProvider := syncer.Providers()
+		rd := Readiness{
+			Paused:      s.Paused(),
+			LastSuccess: lastSuccess,
+			Checks: map[string]HealthCheck{
+				"providers": {OK: ipAddressProvider != nil && dnsProvider != nil},
+				"sync":      {OK: !lastSuccess.IsZero() && time.Since(lastSuccess) < config.ReadinessWindow},
+			},
+			Providers: syncer.ProviderHealth(),
+		}
+
+		if err := s.ReloadError(); err != nil {
+			rd.ConfigReloadError = err.Error()
+		}
+		if lastSuccess.IsZero() {
+			rd.Checks["sync"] = HealthCheck{Detail: "no successful synchronization yet"}
+		} else if !rd.Checks["sync"].OK {
+			rd.Checks["sync"] = HealthCheck{Detail: "last successful synchronization is older than " + config.ReadinessWindow.String()}
+		}
+
+		rd.OK = true
+		for _, c := range rd.Checks {
+			rd.OK = rd.OK && c.OK
+		}
+
+		status := http.StatusOK
+		if !rd.OK {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, rd)
+	}
+}
+
+// providerName Returns the type name of the provider, e.g. CloudflareDNSProvider
+func providerName(provider any) string {
+	t := reflect.TypeOf(provider)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func serveHealth(t *testing.T, s *Scheduler, config *HealthConfig, path string, v any) int {
+	router := httprouter.New()
+	RegisterHealth(router, s, config)
+
+	req, _ := http.NewRequest("GET", path, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	return rr.Code
+}
+
+// TestLiveness tests that the liveness endpoint fails once an iteration exceeds the liveness timeout
+func TestLiveness(t *testing.T) {
+	s, _ := newTestScheduler(nil)
+
+	var l Liveness
+	if code := serveHealth(t, s, defaultHealthConfig, "/healthz", &l); code != http.StatusOK || !l.OK {
+		t.Errorf("got status %d and %+v, wanted healthy", code, l)
+	}
+
+	s.busySince = time.Now().Add(-time.Hour)
+	if code := serveHealth(t, s, defaultHealthConfig, "/healthz", &l); code != http.StatusServiceUnavailable || l.OK {
+		t.Errorf("got status %d and %+v, wanted wedged", code, l)
+	}
+}
+
+// TestReadiness tests that the readiness endpoint reflects the outcome of the last synchronizations
+func TestReadiness(t *testing.T) {
+	c := defaultConfig
+	global.Store(&c)
+	d := newFakeDNSProvider()
+	syncer := NewSyncer(&c, &fakeIPAddressProvider{address: "10.0.0.1"}, d, false)
+	s := NewScheduler(&c, syncer, nil)
+
+	var rd Readiness
+	if code := serveHealth(t, s, defaultHealthConfig, "/readyz", &rd); code != http.StatusServiceUnavailable || rd.OK {
+		t.Errorf("got status %d and %+v, wanted not ready before the first synchronization", code, rd)
+	}
+
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if code := serveHealth(t, s, defaultHealthConfig, "/readyz", &rd); code != http.StatusOK || !rd.OK {
+		t.Errorf("got status %d and %+v, wanted ready", code, rd)
+	}
+
+	d.err = errors.New("boom")
+	_ = syncer.Sync()
+	serveHealth(t, s, defaultHealthConfig, "/readyz", &rd)
+	dns := rd.Providers["dnsProvider"]
+	if dns.Healthy || dns.LastError != "boom" || dns.Name != "fakeDNSProvider" {
+		t.Errorf("unexpected dns provider health %+v", dns)
+	}
+	if !rd.Providers["ipAddressProvider"].Healthy {
+		t.Errorf("unexpected ip address provider health %+v", rd.Providers["ipAddressProvider"])
+	}
+
+	if code := serveHealth(t, s, &HealthConfig{ReadinessWindow: time.Nanosecond}, "/readyz", &rd); code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, wanted not ready once the readiness window elapsed", code)
+	}
+}
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HetznerDNSProviderConfig Configuration for the Hetzner DNS Provider
+type HetznerDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_HETZNER_PROVIDER_ENABLE" required:"false"`
+
+	// Hetzner DNS API token
+	APIToken string `yaml:"apiToken" envconfig:"DDNS_HETZNER_PROVIDER_API_TOKEN" required:"false" secret:"true"`
+
+	// Hetzner DNS zone id
+	ZoneID string `yaml:"zoneID" envconfig:"DDNS_HETZNER_PROVIDER_ZONE_ID" required:"false"`
+
+	// Url of the Hetzner DNS API
+	BaseURL string `yaml:"baseURL" envconfig:"DDNS_HETZNER_PROVIDER_BASE_URL" required:"false"`
+
+	// TTL of created records in seconds, the zone default is used if 0
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_HETZNER_PROVIDER_TTL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_HETZNER_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultHetznerDNSProviderConfig = &HetznerDNSProviderConfig{
+	Enable:   false,
+	APIToken: "",
+	ZoneID:   "",
+	BaseURL:  "https://dns.hetzner.com/api/v1",
+	TTL:      0,
+	ARecords: nil,
+}
+
+type hetznerRecord struct {
+	ID     string `json:"id,omitempty"`
+	ZoneID string `json:"zone_id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	TTL    int64  `json:"ttl,omitempty"`
+}
+
+type hetznerListRecordsResponse struct {
+	Records []hetznerRecord `json:"records"`
+	Meta    struct {
+		Pagination struct {
+			Page     int `json:"page"`
+			LastPage int `json:"last_page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+type hetznerGetZoneResponse struct {
+	Zone struct {
+		Name string `json:"name"`
+	} `json:"zone"`
+}
+
+// hetznerPageSize Number of records requested per page
+const hetznerPageSize = 100
+
+// HetznerDNSProvider Hetzner DNS Provider
+type HetznerDNSProvider struct {
+	apiToken string
+	zoneID   string
+	baseURL  string
+	ttl      int64
+	aRecords []string
+	client   *http.Client
+
+	mu       sync.Mutex
+	zoneName string
+}
+
+// NewHetznerDNSProvider Returns an instance of HetznerDNSProvider based on the passed configuration
+func NewHetznerDNSProvider(config *HetznerDNSProviderConfig) *HetznerDNSProvider {
+	return &HetznerDNSProvider{
+		apiToken: config.APIToken,
+		zoneID:   config.ZoneID,
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		ttl:      config.TTL,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the id and ip address are empty if a name has no A record yet. Names outside of the zone are an error, since the zone
+// name is only known from the API they cannot be rejected by the validation.
+func (h *HetznerDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	zoneName, err := h.zone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range h.aRecords {
+		if !dns.IsSubDomain(dns.Fqdn(zoneName), dns.Fqdn(name)) {
+			return nil, fmt.Errorf("A record %s is not part of the zone %s", name, zoneName)
+		}
+	}
+
+	var records []hetznerRecord
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("zone_id", h.zoneID)
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(hetznerPageSize))
+
+		var r hetznerListRecordsResponse
+		if err := h.do(ctx, "GET", "/records?"+q.Encode(), nil, &r); err != nil {
+			return nil, fmt.Errorf("could not list records of zone %s: %w", h.zoneID, err)
+		}
+		records = append(records, r.Records...)
+
+		if page >= r.Meta.Pagination.LastPage || len(r.Records) == 0 {
+			break
+		}
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range h.aRecords {
+		m := RecordAddressMapping{ARecord: name}
+		for _, r := range records {
+			if r.Type == "A" && r.Name == relativeRecordName(name, zoneName) {
+				m.ID = r.ID
+				m.IPAddress = r.Value
+				break
+			}
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Update the A record to the provided ip address, the record is created if it does not exist yet
+func (h *HetznerDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	zoneName, err := h.zone(ctx)
+	if err != nil {
+		return err
+	}
+
+	record := hetznerRecord{
+		ZoneID: h.zoneID,
+		Type:   "A",
+		Name:   relativeRecordName(m.ARecord, zoneName),
+		Value:  ipAddress,
+		TTL:    h.ttl,
+	}
+
+	if m.ID == "" {
+		return h.do(ctx, "POST", "/records", record, nil)
+	}
+	return h.do(ctx, "PUT", "/records/"+url.PathEscape(m.ID), record, nil)
+}
+
+// zone Returns the name of the zone the record names are relative to, it is only requested once
+func (h *HetznerDNSProvider) zone(ctx context.Context) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.zoneName != "" {
+		return h.zoneName, nil
+	}
+
+	var zone hetznerGetZoneResponse
+	if err := h.do(ctx, "GET", "/zones/"+url.PathEscape(h.zoneID), nil, &zone); err != nil {
+		return "", fmt.Errorf("could not get zone %s: %w", h.zoneID, err)
+	}
+	h.zoneName = zone.Zone.Name
+
+	return h.zoneName, nil
+}
+
+// do Send a request with the json encoded payload to the API and decode the json response into v if not nil
+func (h *HetznerDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, h.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-API-Token", h.apiToken)
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
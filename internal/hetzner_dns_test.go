@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHetznerDNS An in-process stand-in for the Hetzner DNS zone and record APIs returning two records per page
+type fakeHetznerDNS struct {
+	mu      sync.Mutex
+	records []hetznerRecord
+	pages   int
+	zones   int
+}
+
+func (f *fakeHetznerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Auth-API-Token") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/zones/z1":
+		f.zones++
+		_, _ = fmt.Fprint(w, `{"zone":{"id":"z1","name":"example.com"}}`)
+	case r.Method == "GET" && r.URL.Path == "/records" && r.URL.Query().Get("zone_id") == "z1":
+		f.pages++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		res := hetznerListRecordsResponse{}
+		res.Meta.Pagination.Page = page
+		res.Meta.Pagination.LastPage = (len(f.records) + 1) / 2
+		for i := (page - 1) * 2; i < page*2 && i < len(f.records); i++ {
+			res.Records = append(res.Records, f.records[i])
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	case r.Method == "POST" && r.URL.Path == "/records":
+		var record hetznerRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		record.ID = fmt.Sprintf("r%d", len(f.records)+1)
+		f.records = append(f.records, record)
+		_ = json.NewEncoder(w).Encode(map[string]any{"record": record})
+	case r.Method == "PUT":
+		var record hetznerRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		for i := range f.records {
+			if "/records/"+f.records[i].ID == r.URL.Path {
+				record.ID = f.records[i].ID
+				f.records[i] = record
+				_ = json.NewEncoder(w).Encode(map[string]any{"record": record})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestHetznerDNSProvider tests that records are listed across pages, updated and created and the zone is requested once
+func TestHetznerDNSProvider(t *testing.T) {
+	f := &fakeHetznerDNS{records: []hetznerRecord{
+		{ID: "r1", ZoneID: "z1", Type: "NS", Name: "@", Value: "hydrogen.ns.hetzner.com."},
+		{ID: "r2", ZoneID: "z1", Type: "A", Name: "www", Value: "10.0.0.2"},
+		{ID: "r3", ZoneID: "z1", Type: "A", Name: "@", Value: "10.0.0.1"},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultHetznerDNSProviderConfig
+	c.APIToken = "token"
+	c.ZoneID = "z1"
+	c.BaseURL = s.URL
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewHetznerDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.pages != 2 {
+		t.Errorf("got %d pages, wanted 2", f.pages)
+	}
+	want := []RecordAddressMapping{
+		{ID: "r3", ARecord: "example.com", IPAddress: "10.0.0.1"},
+		{ID: "r2", ARecord: "www.example.com", IPAddress: "10.0.0.2"},
+		{ID: "", ARecord: "new.example.com", IPAddress: ""},
+	}
+	for i, m := range ms {
+		if m != want[i] {
+			t.Errorf("got %v, wanted %v", m, want[i])
+		}
+	}
+
+	for _, m := range ms[1:] {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ms, err = p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if m.IPAddress != "10.0.0.1" || m.ID == "" {
+			t.Errorf("got %v, wanted %s with an id", m, "10.0.0.1")
+		}
+	}
+	if len(f.records) != 4 {
+		t.Errorf("got %d records, wanted 4", len(f.records))
+	}
+	if f.zones != 1 {
+		t.Errorf("got %d zone requests, wanted the zone to be requested once", f.zones)
+	}
+
+	p.apiToken = "wrong"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil {
+		t.Errorf("got no error for a wrong token, wanted one")
+	}
+}
+
+// TestHetznerDNSProviderOutsideZone tests that names outside of the zone are rejected before any record is listed
+func TestHetznerDNSProviderOutsideZone(t *testing.T) {
+	f := &fakeHetznerDNS{}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultHetznerDNSProviderConfig
+	c.APIToken = "token"
+	c.ZoneID = "z1"
+	c.BaseURL = s.URL
+	c.ARecords = StringList{"www.example.com", "www.example.org"}
+	p := NewHetznerDNSProvider(&c)
+
+	_, err := p.GetARecordAddresses(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "www.example.org is not part of the zone example.com") {
+		t.Errorf("got %v, wanted an error for www.example.org", err)
+	}
+	if f.pages != 0 {
+		t.Errorf("got %d pages, wanted none", f.pages)
+	}
+}
@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HookConfig Configuration of a single command run before or after A records are updated
+type HookConfig struct {
+	// Executable and arguments of the command, not interpreted by a shell
+	Command []string `yaml:"command"`
+
+	// Go duration after which the command is killed, the hooks timeout if unset
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Switch to abort the update if this pre-update command fails, ignored for post-update commands
+	Veto bool `yaml:"veto"`
+}
+
+// HooksConfig Config section governing commands run before and after A records are updated
+type HooksConfig struct {
+	// Default go duration after which a command is killed
+	Timeout time.Duration `yaml:"timeout" envconfig:"DDNS_HOOKS_TIMEOUT" required:"false"`
+
+	// Commands run before A records are updated
+	PreUpdate []HookConfig `yaml:"preUpdate" ignored:"true"`
+
+	// Commands run after A records were updated or failed to update
+	PostUpdate []HookConfig `yaml:"postUpdate" ignored:"true"`
+}
+
+var defaultHooksConfig = &HooksConfig{
+	Timeout:    30 * time.Second,
+	PreUpdate:  nil,
+	PostUpdate: nil,
+}
+
+const (
+	hookPhasePreUpdate  = "pre_update"
+	hookPhasePostUpdate = "post_update"
+
+	hookOutcomePending = "pending"
+	hookOutcomeSuccess = "success"
+	hookOutcomeFailure = "failure"
+)
+
+// HookRecord An A record passed to hook commands
+type HookRecord struct {
+	ARecord    string `json:"aRecord"`
+	OldAddress string `json:"oldAddress"`
+	NewAddress string `json:"newAddress"`
+	Updated    bool   `json:"updated"`
+}
+
+// HookPayload The json document passed to hook commands on stdin
+type HookPayload struct {
+	Phase      string       `json:"phase"`
+	OldAddress string       `json:"oldAddress"`
+	NewAddress string       `json:"newAddress"`
+	Records    []HookRecord `json:"records"`
+	Outcome    string       `json:"outcome"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// env Returns the payload as environment variables
+func (p *HookPayload) env() []string {
+	var names []string
+	for _, r := range p.Records {
+		names = append(names, r.ARecord)
+	}
+
+	return []string{
+		"DDNS_HOOK_PHASE=" + p.Phase,
+		"DDNS_OLD_ADDRESS=" + p.OldAddress,
+		"DDNS_NEW_ADDRESS=" + p.NewAddress,
+		"DDNS_RECORDS=" + strings.Join(names, ","),
+		"DDNS_OUTCOME=" + p.Outcome,
+		"DDNS_ERROR=" + p.Error,
+	}
+}
+
+// hookPassthroughEnv Environment variables of ddns passed on to hooks, everything else, especially secrets, is withheld
+var hookPassthroughEnv = []string{"PATH", "HOME"}
+
+// hookEnv Returns the minimal environment of a hook for the payload
+func hookEnv(p *HookPayload) []string {
+	var env []string
+	for _, name := range hookPassthroughEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return append(env, p.env()...)
+}
+
+// newHookPayload Returns the payload for the updates, the outcome is derived from whether updated is set and err
+func newHookPayload(phase string, updates []PlannedRecord, updated map[string]bool, err error) *HookPayload {
+	p := &HookPayload{Phase: phase, Outcome: hookOutcomePending, Records: []HookRecord{}}
+	if phase == hookPhasePostUpdate {
+		p.Outcome = hookOutcomeSuccess
+	}
+	if err != nil {
+		p.Outcome = hookOutcomeFailure
+		p.Error = err.Error()
+	}
+
+	for _, r := range updates {
+		if p.OldAddress == "" {
+			p.OldAddress = r.CurrentIPAddress
+		}
+		p.NewAddress = r.DesiredIPAddress
+		p.Records = append(p.Records, HookRecord{
+			ARecord:    r.ARecord,
+			OldAddress: r.CurrentIPAddress,
+			NewAddress: r.DesiredIPAddress,
+			Updated:    updated[r.ARecord],
+		})
+	}
+
+	return p
+}
+
+// Hooks Runs the configured commands before and after A records are updated
+type Hooks struct {
+	timeout    time.Duration
+	preUpdate  []HookConfig
+	postUpdate []HookConfig
+}
+
+// NewHooks Returns an instance of Hooks based on the passed configuration
+func NewHooks(config *HooksConfig) *Hooks {
+	return &Hooks{
+		timeout:    config.Timeout,
+		preUpdate:  config.PreUpdate,
+		postUpdate: config.PostUpdate,
+	}
+}
+
+// RunPreUpdate Run all pre-update commands, returns an error if a vetoing command failed
+func (h *Hooks) RunPreUpdate(ctx context.Context, p *HookPayload) error {
+	for _, hook := range h.preUpdate {
+		if err := h.run(ctx, hook, p); err != nil && hook.Veto {
+			return fmt.Errorf("update vetoed by pre-update hook %s: %w", hook.Command[0], err)
+		}
+	}
+	return nil
+}
+
+// RunPostUpdate Run all post-update commands, failures are only logged
+func (h *Hooks) RunPostUpdate(ctx context.Context, p *HookPayload) {
+	for _, hook := range h.postUpdate {
+		_ = h.run(ctx, hook, p)
+	}
+}
+
+// run Run the command with the payload on stdin and as environment variables
+func (h *Hooks) run(ctx context.Context, hook HookConfig, p *HookPayload) error {
+	if len(hook.Command) == 0 {
+		return nil
+	}
+
+	timeout := hook.Timeout
+	if timeout == 0 {
+		timeout = h.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdin, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Env = hookEnv(p)
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	log.Info().Msgf("Running %s hook %s", p.Phase, hook.Command[0])
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		log.Debug().Msgf("Output of %s hook %s: %s", p.Phase, hook.Command[0], out)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		log.Error().Msgf("The %s hook %s failed: %s", p.Phase, hook.Command[0], err)
+		ErrorsCounter.WithLabelValues(errorClassHook).Inc()
+	}
+
+	return err
+}
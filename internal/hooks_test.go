@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hookScript Returns a hook command that appends its environment and stdin to the file and exits with the code
+func hookScript(out string, code int) []string {
+	return []string{"/bin/sh", "-c", `echo "$DDNS_HOOK_PHASE $DDNS_OLD_ADDRESS $DDNS_NEW_ADDRESS $DDNS_RECORDS $DDNS_OUTCOME" >> "$0"; cat >> "$0"; echo >> "$0"; exit ` + strconv.Itoa(code), out}
+}
+
+func readHookOutput(t *testing.T, out string) []string {
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+// TestHooks tests that pre and post update hooks receive the addresses, records and outcome
+func TestHooks(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hooks")
+	c := defaultConfig
+	c.HooksConfig.PreUpdate = []HookConfig{{Command: hookScript(out, 0), Veto: true}}
+	c.HooksConfig.PostUpdate = []HookConfig{{Command: hookScript(out, 1)}}
+
+	d := newFakeDNSProvider()
+	if err := NewSyncer(&c, &fakeIPAddressProvider{address: "10.0.0.1"}, d, false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	lines := readHookOutput(t, out)
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines of hook output, wanted 4: %v", len(lines), lines)
+	}
+	if lines[0] != "pre_update 10.0.0.2 10.0.0.1 www.example.com pending" {
+		t.Errorf("unexpected pre update environment %s", lines[0])
+	}
+	if lines[2] != "post_update 10.0.0.2 10.0.0.1 www.example.com success" {
+		t.Errorf("unexpected post update environment %s", lines[2])
+	}
+
+	var p HookPayload
+	if err := json.Unmarshal([]byte(lines[3]), &p); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(p.Records) != 1 || !p.Records[0].Updated || p.Outcome != hookOutcomeSuccess {
+		t.Errorf("unexpected post update payload %+v", p)
+	}
+}
+
+// TestHooksEnvironment tests that hooks only receive PATH, HOME and their own variables, not the secrets of ddns
+func TestHooksEnvironment(t *testing.T) {
+	t.Setenv("DDNS_CLOUDFLARE_API_TOKEN", "secret")
+	t.Setenv("HOME", "/home/ddns")
+	out := filepath.Join(t.TempDir(), "env")
+	c := defaultConfig
+	c.HooksConfig.PostUpdate = []HookConfig{{Command: []string{"/bin/sh", "-c", `env > "$0"`, out}}}
+
+	if err := NewSyncer(&c, &fakeIPAddressProvider{address: "10.0.0.1"}, newFakeDNSProvider(), false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	env := map[string]string{}
+	for _, line := range readHookOutput(t, out) {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			env[name] = value
+		}
+	}
+	if _, ok := env["DDNS_CLOUDFLARE_API_TOKEN"]; ok {
+		t.Error("got DDNS_CLOUDFLARE_API_TOKEN, wanted secrets to be withheld")
+	}
+	if env["HOME"] != "/home/ddns" || env["PATH"] != os.Getenv("PATH") || env["DDNS_HOOK_PHASE"] != hookPhasePostUpdate {
+		t.Errorf("got %v, wanted PATH, HOME and the hook variables", env)
+	}
+}
+
+// TestHooksVeto tests that a failing vetoing pre update hook prevents the update
+func TestHooksVeto(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hooks")
+	c := defaultConfig
+	c.HooksConfig.PreUpdate = []HookConfig{{Command: hookScript(out, 1), Veto: true}}
+
+	d := newFakeDNSProvider()
+	err := NewSyncer(&c, &fakeIPAddressProvider{address: "10.0.0.1"}, d, false).Sync()
+	if err == nil || !strings.Contains(err.Error(), "vetoed") {
+		t.Errorf("got %v, wanted veto error", err)
+	}
+	if len(d.sets) != 0 {
+		t.Errorf("got %d updates, wanted none", len(d.sets))
+	}
+
+	c.HooksConfig.PreUpdate[0].Veto = false
+	if err := NewSyncer(&c, &fakeIPAddressProvider{address: "10.0.0.1"}, d, false).Sync(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if len(d.sets) != 1 {
+		t.Errorf("got %d updates, wanted 1", len(d.sets))
+	}
+}
+
+// TestHooksTimeout tests that hooks are killed after their timeout
+func TestHooksTimeout(t *testing.T) {
+	h := NewHooks(&HooksConfig{Timeout: 50 * time.Millisecond, PreUpdate: []HookConfig{{Command: []string{"sleep", "5"}, Veto: true}}})
+
+	start := time.Now()
+	err := h.RunPreUpdate(context.Background(), newHookPayload(hookPhasePreUpdate, nil, nil, nil))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("got %v, wanted timeout error", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("expected hook to be killed")
+	}
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HostsFileDNSProviderConfig Configuration for the hosts file DNS Provider
+type HostsFileDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_HOSTSFILE_PROVIDER_ENABLE" required:"false"`
+
+	// Path of the file containing the managed block, it is created if it does not exist
+	Path string `yaml:"path" envconfig:"DDNS_HOSTSFILE_PROVIDER_PATH" required:"false"`
+
+	// Format of the entries, hosts for "<ip> <name>" lines or dnsmasq for "address=/<name>/<ip>" lines
+	Format string `yaml:"format" envconfig:"DDNS_HOSTSFILE_PROVIDER_FORMAT" required:"false"`
+
+	// Pid file of the process that is signaled after the file was written, no signal is sent if empty
+	PIDFile string `yaml:"pidFile" envconfig:"DDNS_HOSTSFILE_PROVIDER_PID_FILE" required:"false"`
+
+	// Signal sent to the process, one of SIGHUP, SIGINT, SIGQUIT or SIGTERM
+	Signal string `yaml:"signal" envconfig:"DDNS_HOSTSFILE_PROVIDER_SIGNAL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_HOSTSFILE_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultHostsFileDNSProviderConfig = &HostsFileDNSProviderConfig{
+	Enable:   false,
+	Path:     "",
+	Format:   "hosts",
+	PIDFile:  "",
+	Signal:   "SIGHUP",
+	ARecords: nil,
+}
+
+const (
+	hostsFileBlockBegin = "# BEGIN ddns managed block, do not edit"
+	hostsFileBlockEnd   = "# END ddns managed block"
+)
+
+// hostsFileSignals Signals that can be sent to the process, limited to the ones available on all platforms
+var hostsFileSignals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// HostsFileDNSProvider hosts file DNS Provider, only the block between the markers is managed and the rest of the file
+// is kept as is
+type HostsFileDNSProvider struct {
+	path     string
+	format   string
+	pidFile  string
+	signal   syscall.Signal
+	aRecords []string
+
+	mu sync.Mutex
+
+	// signalPending Is true if the file was written since the process was last signaled successfully
+	signalPending bool
+}
+
+// NewHostsFileDNSProvider Returns an instance of HostsFileDNSProvider based on the passed configuration
+func NewHostsFileDNSProvider(config *HostsFileDNSProviderConfig) *HostsFileDNSProvider {
+	return &HostsFileDNSProvider{
+		path:     config.Path,
+		format:   config.Format,
+		pidFile:  config.PIDFile,
+		signal:   hostsFileSignals[config.Signal],
+		aRecords: config.ARecords,
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the ip addresses of the managed block for names specified in
+// the configuration, the ip address is empty if a name is not part of the block yet. A failed signal is retried first, as
+// the file already contains the addresses the process may not serve yet.
+func (h *HostsFileDNSProvider) GetARecordAddresses(_ context.Context) ([]RecordAddressMapping, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.signalIfPending(); err != nil {
+		return nil, err
+	}
+
+	_, current, _, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range h.aRecords {
+		ms = append(ms, RecordAddressMapping{ID: name, ARecord: name, IPAddress: current[strings.ToLower(name)]})
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Rewrite the managed block with the provided ip address for the name, atomically replace the file and
+// signal the process if configured
+func (h *HostsFileDNSProvider) SetARecordAddress(_ context.Context, ipAddress string, m RecordAddressMapping) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	before, current, after, err := h.read()
+	if err != nil {
+		return err
+	}
+	current[strings.ToLower(m.ARecord)] = ipAddress
+
+	// Names that are no longer configured are dropped from the block
+	lines := append(before, hostsFileBlockBegin)
+	for _, name := range h.aRecords {
+		if ip := current[strings.ToLower(name)]; ip != "" {
+			lines = append(lines, h.entry(name, ip))
+		}
+	}
+	lines = append(lines, hostsFileBlockEnd)
+	lines = append(lines, after...)
+
+	perm := fs.FileMode(0o644)
+	if info, err := os.Stat(h.path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(h.path, []byte(strings.Join(lines, "\n")+"\n"), perm); err != nil {
+		return fmt.Errorf("could not write %s: %w", h.path, err)
+	}
+
+	h.signalPending = h.pidFile != ""
+	return h.signalIfPending()
+}
+
+// read Returns the lines before the managed block, the ip addresses of the entries in the block by lower case name and
+// the lines after the block. A missing file is treated as empty.
+func (h *HostsFileDNSProvider) read() ([]string, map[string]string, []string, error) {
+	current := map[string]string{}
+
+	b, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, current, nil, nil
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not read %s: %w", h.path, err)
+	}
+
+	var before, after []string
+	inBlock, seenBlock := false, false
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		switch {
+		case !seenBlock && strings.TrimSpace(line) == hostsFileBlockBegin:
+			inBlock, seenBlock = true, true
+		case inBlock && strings.TrimSpace(line) == hostsFileBlockEnd:
+			inBlock = false
+		case inBlock:
+			if name, ip, ok := h.parse(line); ok {
+				current[strings.ToLower(name)] = ip
+			}
+		case seenBlock:
+			after = append(after, line)
+		default:
+			before = append(before, line)
+		}
+	}
+	if inBlock {
+		return nil, nil, nil, fmt.Errorf("the managed block of %s is not terminated by %q", h.path, hostsFileBlockEnd)
+	}
+
+	return before, current, after, nil
+}
+
+// entry Returns the line of the name and ip address in the configured format
+func (h *HostsFileDNSProvider) entry(name string, ip string) string {
+	if h.format == "dnsmasq" {
+		return fmt.Sprintf("address=/%s/%s", name, ip)
+	}
+	return fmt.Sprintf("%s\t%s", ip, name)
+}
+
+// parse Returns the name and ip address of a line in the configured format
+func (h *HostsFileDNSProvider) parse(line string) (string, string, bool) {
+	if h.format == "dnsmasq" {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "address=/")
+		parts := strings.Split(rest, "/")
+		if !ok || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", false
+		}
+		return parts[0], parts[1], true
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+		return "", "", false
+	}
+	return fields[1], fields[0], true
+}
+
+// signalIfPending Signal the process if the file was written since it was last signaled successfully
+func (h *HostsFileDNSProvider) signalIfPending() error {
+	if !h.signalPending {
+		return nil
+	}
+	if err := h.signalProcess(); err != nil {
+		return fmt.Errorf("could not signal the process of %s: %w", h.pidFile, err)
+	}
+	h.signalPending = false
+	return nil
+}
+
+// signalProcess Send the configured signal to the process whose id is stored in the pid file
+func (h *HostsFileDNSProvider) signalProcess() error {
+	b, err := os.ReadFile(h.pidFile)
+	if err != nil {
+		return err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return fmt.Errorf("%q is not a process id", strings.TrimSpace(string(b)))
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	log.Debug().Msgf("Sending %s to process %d", h.signal, pid)
+	return process.Signal(h.signal)
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestHostsFileDNSProvider tests that only the managed block is rewritten in both formats
+func TestHostsFileDNSProvider(t *testing.T) {
+	tests := []struct {
+		format string
+		before string
+		after  string
+	}{
+		{
+			format: "hosts",
+			before: "127.0.0.1\tlocalhost\n" + hostsFileBlockBegin + "\n10.0.0.1\thome.lan\n10.0.0.9\tremoved.lan\n" + hostsFileBlockEnd + "\n# kept\n",
+			after:  "127.0.0.1\tlocalhost\n" + hostsFileBlockBegin + "\n10.0.0.2\thome.lan\n" + hostsFileBlockEnd + "\n# kept\n",
+		},
+		{
+			format: "dnsmasq",
+			before: "address=/static.lan/10.0.0.5\n",
+			after:  "address=/static.lan/10.0.0.5\n" + hostsFileBlockBegin + "\naddress=/home.lan/10.0.0.2\n" + hostsFileBlockEnd + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "hosts")
+		if err := os.WriteFile(path, []byte(test.before), 0o640); err != nil {
+			t.Fatal(err)
+		}
+
+		c := *defaultHostsFileDNSProviderConfig
+		c.Path = path
+		c.Format = test.format
+		c.ARecords = StringList{"home.lan"}
+		p := NewHostsFileDNSProvider(&c)
+
+		ms, err := p.GetARecordAddresses(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.2", ms[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		b, _ := os.ReadFile(path)
+		if string(b) != test.after {
+			t.Errorf("got %q, wanted %q", b, test.after)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+			t.Errorf("got %s, wanted %s", info.Mode().Perm(), os.FileMode(0o640))
+		}
+
+		ms, err = p.GetARecordAddresses(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ms[0].IPAddress != "10.0.0.2" {
+			t.Errorf("got %s, wanted %s", ms[0].IPAddress, "10.0.0.2")
+		}
+	}
+}
+
+// TestHostsFileDNSProviderSignal tests that the process of the pid file is signaled after the file was written
+func TestHostsFileDNSProviderSignal(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "dnsmasq.pid")
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := *defaultHostsFileDNSProviderConfig
+	c.Path = filepath.Join(dir, "hosts")
+	c.PIDFile = pidFile
+	c.ARecords = StringList{"home.lan"}
+	p := NewHostsFileDNSProvider(&c)
+
+	if err := p.SetARecordAddress(context.Background(), "10.0.0.2", RecordAddressMapping{ARecord: "home.lan"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case s := <-signals:
+		if s != syscall.SIGHUP {
+			t.Errorf("got %s, wanted %s", s, syscall.SIGHUP)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("got no signal, wanted SIGHUP")
+	}
+}
+
+// TestHostsFileDNSProviderSignalRetry tests that a failed signal is retried until it succeeds
+func TestHostsFileDNSProviderSignalRetry(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "dnsmasq.pid")
+
+	c := *defaultHostsFileDNSProviderConfig
+	c.Path = filepath.Join(dir, "hosts")
+	c.PIDFile = pidFile
+	c.ARecords = StringList{"home.lan"}
+	p := NewHostsFileDNSProvider(&c)
+
+	if err := p.SetARecordAddress(context.Background(), "10.0.0.2", RecordAddressMapping{ARecord: "home.lan"}); err == nil {
+		t.Fatal("got nil, wanted an error for the missing pid file")
+	}
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil {
+		t.Fatal("got nil, wanted the failed signal to be retried")
+	}
+
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetARecordAddresses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-signals:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got no signal, wanted SIGHUP")
+	}
+
+	if _, err := p.GetARecordAddresses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-signals:
+		t.Error("got another signal, wanted the process to be signaled only until it succeeded")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
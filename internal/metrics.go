@@ -31,8 +31,92 @@ var (
 		},
 		[]string{"ip_address", "a_record"},
 	)
+	DNSRecordUpdatesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ddns_dns_record_updates_total",
+			Help: "Number of successful a record updates, labeled by a record and dns provider.",
+		},
+		[]string{"a_record", "provider"},
+	)
+	ErrorsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ddns_errors_total",
+			Help: "Number of errors, labeled by class.",
+		},
+		[]string{"class"},
+	)
+	DetectedIPAddressInfoGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ddns_detected_ip_address_info",
+			Help: "Metric with a constant '1' value showing the ip address last obtained from the ip address provider.",
+		},
+		[]string{"ip_address", "source"},
+	)
+	LastSuccessfulSyncGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ddns_last_successful_sync_timestamp_seconds",
+			Help: "Time of the last successful synchronization since unix epoch in seconds.",
+		},
+		[]string{},
+	)
+	LastChangeGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ddns_last_change_timestamp_seconds",
+			Help: "Time of the last update of an a record since unix epoch in seconds, labeled by a record.",
+		},
+		[]string{"a_record"},
+	)
+	ProviderRequestDurationHistogram = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ddns_provider_request_duration_seconds",
+			Help:    "Duration of calls to the ip address and dns providers in seconds, labeled by provider and operation.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"provider", "operation"},
+	)
+	SuppressedUpdatesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ddns_suppressed_updates_total",
+			Help: "Number of a record updates suppressed by the stability policy, labeled by a record and reason.",
+		},
+		[]string{"a_record", "reason"},
+	)
+	ConfigReloadsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ddns_config_reloads_total",
+			Help: "Number of config reloads, labeled by result.",
+		},
+		[]string{"result"},
+	)
+	ConfigLastReloadSuccessGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ddns_config_last_reload_successful",
+			Help: "Whether the last config reload was successful (1) or kept the previous config (0).",
+		},
+		[]string{},
+	)
+)
+
+const (
+	errorClassIPAddressProvider = "ip_address_provider"
+	errorClassDNSProviderRead   = "dns_provider_read"
+	errorClassDNSProviderWrite  = "dns_provider_write"
+	errorClassState             = "state"
+	errorClassNotification      = "notification"
+	errorClassHook              = "hook"
 )
 
+// setInfoGauge Set the info gauge for the label to the value and delete all series with a different value for the label
+func setInfoGauge(g *prometheus.GaugeVec, valueLabel string, value string, label string, labelValue string) {
+	g.DeletePartialMatch(prometheus.Labels{label: labelValue})
+	g.With(prometheus.Labels{valueLabel: value, label: labelValue}).Set(1)
+}
+
+// deleteInfoGauge Delete all series of the info gauge for the label
+func deleteInfoGauge(g *prometheus.GaugeVec, label string, labelValue string) {
+	g.DeletePartialMatch(prometheus.Labels{label: labelValue})
+}
+
 // Metrics Return a httprouter.Handle function that handles metrics requests
 func Metrics() httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
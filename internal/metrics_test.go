@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"errors"
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"net/http"
 	"net/http/httptest"
 	"runtime"
@@ -42,3 +44,76 @@ func TestMetrics(t *testing.T) {
 		t.Errorf("Expected response body to contain ddns_dns_a_record_info, got %s", rr.Body.String())
 	}
 }
+
+// TestRecordInfoStaleSeries tests that the a record info metric only reports the current ip address of a record
+func TestRecordInfoStaleSeries(t *testing.T) {
+	d := &fakeDNSProvider{records: []RecordAddressMapping{{ID: "1", ARecord: "stale.example.com", IPAddress: "10.0.0.1"}}}
+	i := &fakeIPAddressProvider{address: "10.0.0.1"}
+	s := NewSyncer(&defaultConfig, i, d, false)
+
+	for _, a := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		i.address = a
+		if err := s.Sync(); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+
+	if got := testutil.ToFloat64(DNSARecordInfoGauge.WithLabelValues("10.0.0.3", "stale.example.com")); got != 1 {
+		t.Errorf("got %v for the current ip address, wanted 1", got)
+	}
+	for _, a := range []string{"10.0.0.1", "10.0.0.2"} {
+		if DNSARecordInfoGauge.DeleteLabelValues(a, "stale.example.com") {
+			t.Errorf("expected no series for the stale ip address %s", a)
+		}
+	}
+
+	if got := testutil.ToFloat64(DNSRecordUpdatesCounter.WithLabelValues("stale.example.com", "fakeDNSProvider")); got != 2 {
+		t.Errorf("got %v updates, wanted 2", got)
+	}
+}
+
+// TestRecordInfoUnconfigured tests that the a record info series of records removed from the config are deleted on
+// reconfiguration
+func TestRecordInfoUnconfigured(t *testing.T) {
+	c := defaultConfig
+	c.CloudflareDNSProviderConfig.Enable = true
+	c.CloudflareDNSProviderConfig.ARecords = StringList{"kept.example.com", "removed.example.com"}
+	d := &fakeDNSProvider{records: []RecordAddressMapping{
+		{ID: "1", ARecord: "kept.example.com", IPAddress: "10.0.0.1"},
+		{ID: "2", ARecord: "removed.example.com", IPAddress: "10.0.0.1"},
+	}}
+	i := &fakeIPAddressProvider{address: "10.0.0.1"}
+	s := NewSyncer(&c, i, d, false)
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	next := c
+	next.CloudflareDNSProviderConfig.ARecords = StringList{"kept.example.com"}
+	s.Reconfigure(&next, i, d)
+
+	if got := testutil.ToFloat64(DNSARecordInfoGauge.WithLabelValues("10.0.0.1", "kept.example.com")); got != 1 {
+		t.Errorf("got %v for kept.example.com, wanted 1", got)
+	}
+	if DNSARecordInfoGauge.DeleteLabelValues("10.0.0.1", "removed.example.com") {
+		t.Error("expected no series for removed.example.com")
+	}
+	if records := s.Records(); len(records) != 1 || records[0].ARecord != "kept.example.com" {
+		t.Errorf("got %v, wanted only kept.example.com", records)
+	}
+}
+
+// TestErrorsCounter tests that provider errors are counted by class
+func TestErrorsCounter(t *testing.T) {
+	before := testutil.ToFloat64(ErrorsCounter.WithLabelValues(errorClassDNSProviderWrite))
+
+	d := newFakeDNSProvider()
+	d.setError = errors.New("boom")
+	if err := NewSyncer(&defaultConfig, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false).Sync(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if got := testutil.ToFloat64(ErrorsCounter.WithLabelValues(errorClassDNSProviderWrite)) - before; got != 1 {
+		t.Errorf("got %v new errors, wanted 1", got)
+	}
+}
@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/zerolog/log"
+)
+
+// MQTTConfig Config section governing publishing the state to an MQTT broker
+type MQTTConfig struct {
+	// Switch to enable or disable publishing to the MQTT broker
+	Enable bool `yaml:"enable" envconfig:"DDNS_MQTT_ENABLE" required:"false"`
+
+	// Url of the broker, e.g. tcp://127.0.0.1:1883 or ssl://broker.example.com:8883
+	Broker string `yaml:"broker" envconfig:"DDNS_MQTT_BROKER" required:"false"`
+
+	// Client id, also used as Home Assistant node id
+	ClientID string `yaml:"clientID" envconfig:"DDNS_MQTT_CLIENT_ID" required:"false"`
+
+	// Username, only set if required
+	Username string `yaml:"username" envconfig:"DDNS_MQTT_USERNAME" required:"false"`
+
+	// Password, only set if required
+	Password string `yaml:"password" envconfig:"DDNS_MQTT_PASSWORD" required:"false" secret:"true"`
+
+	// Prefix of all state and command topics
+	TopicPrefix string `yaml:"topicPrefix" envconfig:"DDNS_MQTT_TOPIC_PREFIX" required:"false"`
+
+	// Switch to enable or disable publishing Home Assistant MQTT discovery configs
+	Discovery bool `yaml:"discovery" envconfig:"DDNS_MQTT_DISCOVERY" required:"false"`
+
+	// Prefix of the Home Assistant MQTT discovery topics
+	DiscoveryPrefix string `yaml:"discoveryPrefix" envconfig:"DDNS_MQTT_DISCOVERY_PREFIX" required:"false"`
+}
+
+var defaultMQTTConfig = &MQTTConfig{
+	Enable:          false,
+	Broker:          "tcp://127.0.0.1:1883",
+	ClientID:        "ddns",
+	TopicPrefix:     "ddns",
+	Discovery:       true,
+	DiscoveryPrefix: "homeassistant",
+}
+
+// mqttPublishTimeout Go duration after which waiting for the broker to acknowledge a message is given up
+const mqttPublishTimeout = 10 * time.Second
+
+const (
+	mqttPayloadOnline  = "online"
+	mqttPayloadOffline = "offline"
+	mqttPayloadOK      = "ok"
+	mqttPayloadFailing = "failing"
+	mqttPayloadSync    = "sync"
+)
+
+type mqttDiscoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version"`
+}
+
+type mqttDiscoveryConfig struct {
+	Name              string              `json:"name"`
+	UniqueID          string              `json:"unique_id"`
+	ObjectID          string              `json:"object_id"`
+	StateTopic        string              `json:"state_topic,omitempty"`
+	CommandTopic      string              `json:"command_topic,omitempty"`
+	PayloadPress      string              `json:"payload_press,omitempty"`
+	PayloadOn         string              `json:"payload_on,omitempty"`
+	PayloadOff        string              `json:"payload_off,omitempty"`
+	DeviceClass       string              `json:"device_class,omitempty"`
+	Icon              string              `json:"icon,omitempty"`
+	AvailabilityTopic string              `json:"availability_topic"`
+	Device            mqttDiscoveryDevice `json:"device"`
+}
+
+// MQTTPublisher Publishes the state of the Scheduler to retained MQTT topics and triggers synchronizations on command
+type MQTTPublisher struct {
+	client          mqtt.Client
+	scheduler       *Scheduler
+	clientID        string
+	topicPrefix     string
+	discovery       bool
+	discoveryPrefix string
+	version         string
+	mu              sync.Mutex
+	records         map[string]bool
+}
+
+// NewMQTTPublisher Returns an instance of MQTTPublisher based on the passed configuration
+func NewMQTTPublisher(config *MQTTConfig, s *Scheduler, version string) *MQTTPublisher {
+	p := &MQTTPublisher{
+		scheduler:       s,
+		clientID:        config.ClientID,
+		topicPrefix:     strings.TrimSuffix(config.TopicPrefix, "/"),
+		discovery:       config.Discovery,
+		discoveryPrefix: strings.TrimSuffix(config.DiscoveryPrefix, "/"),
+		version:         version,
+		records:         map[string]bool{},
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(p.topic("availability"), mqttPayloadOffline, 1, true).
+		SetOnConnectHandler(p.onConnect)
+	p.client = mqtt.NewClient(opts)
+
+	return p
+}
+
+// Start Connect to the broker in the background and publish the state after every synchronization. Connecting is retried
+// until the broker is reachable, only errors that retrying cannot fix, e.g. an invalid broker url, are returned.
+func (p *MQTTPublisher) Start() error {
+	t := p.client.Connect()
+	select {
+	case <-t.Done():
+		if err := t.Error(); err != nil {
+			return err
+		}
+	default:
+		log.Info().Msg("Connecting to mqtt broker in the background")
+	}
+
+	p.scheduler.Subscribe(func(SchedulerStatus) { p.PublishState() })
+	return nil
+}
+
+// Stop Mark ddns as offline and disconnect from the broker
+func (p *MQTTPublisher) Stop() {
+	if p.client.IsConnectionOpen() {
+		p.publish(p.topic("availability"), mqttPayloadOffline).WaitTimeout(mqttPublishTimeout)
+	}
+	p.client.Disconnect(250)
+}
+
+// onConnect Subscribe to the command topic and publish discovery configs and state on every (re)connect
+func (p *MQTTPublisher) onConnect(c mqtt.Client) {
+	log.Info().Msg("Connected to mqtt broker")
+
+	c.Subscribe(p.topic("command"), 1, func(_ mqtt.Client, m mqtt.Message) {
+		if strings.TrimSpace(string(m.Payload())) != mqttPayloadSync {
+			log.Warn().Msgf("Ignoring unknown mqtt command %s", m.Payload())
+			return
+		}
+		if !p.scheduler.Trigger() {
+			log.Warn().Msg("Ignoring mqtt sync command while synchronization is paused")
+		}
+	})
+
+	p.mu.Lock()
+	p.records = map[string]bool{}
+	p.mu.Unlock()
+	if p.discovery {
+		p.publishDiscovery()
+	}
+	p.publish(p.topic("availability"), mqttPayloadOnline)
+	p.PublishState()
+}
+
+// PublishState Publish the detected address, the A records, the last sync time and the health to retained topics
+func (p *MQTTPublisher) PublishState() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := p.scheduler.Status()
+	if status.LastReport == nil {
+		return
+	}
+
+	health := mqttPayloadOK
+	if status.LastReport.Error != "" {
+		health = mqttPayloadFailing
+	}
+	p.publish(p.topic("health"), health)
+
+	if status.LastReport.IPAddress != "" {
+		p.publish(p.topic("address"), status.LastReport.IPAddress)
+	}
+	if lastSuccess := p.scheduler.Syncer().LastSuccess(); !lastSuccess.IsZero() {
+		p.publish(p.topic("last_sync"), lastSuccess.Format(time.RFC3339))
+	}
+
+	for _, r := range p.scheduler.Syncer().Records() {
+		if p.discovery && !p.records[r.ARecord] {
+			p.publishDiscoveryConfig("sensor", "record_"+r.ARecord, mqttDiscoveryConfig{
+				Name:       r.ARecord,
+				StateTopic: p.topic("records/" + r.ARecord),
+				Icon:       "mdi:dns",
+			})
+		}
+		p.records[r.ARecord] = true
+		p.publish(p.topic("records/"+r.ARecord), r.IPAddress)
+	}
+}
+
+// publishDiscovery Publish the Home Assistant discovery configs of all entities that do not depend on the A records
+func (p *MQTTPublisher) publishDiscovery() {
+	p.publishDiscoveryConfig("sensor", "address", mqttDiscoveryConfig{
+		Name:       "IP address",
+		StateTopic: p.topic("address"),
+		Icon:       "mdi:ip-network",
+	})
+	p.publishDiscoveryConfig("sensor", "last_sync", mqttDiscoveryConfig{
+		Name:        "Last sync",
+		StateTopic:  p.topic("last_sync"),
+		DeviceClass: "timestamp",
+	})
+	p.publishDiscoveryConfig("binary_sensor", "health", mqttDiscoveryConfig{
+		Name:        "Sync problem",
+		StateTopic:  p.topic("health"),
+		PayloadOn:   mqttPayloadFailing,
+		PayloadOff:  mqttPayloadOK,
+		DeviceClass: "problem",
+	})
+	p.publishDiscoveryConfig("button", "sync", mqttDiscoveryConfig{
+		Name:         "Sync",
+		CommandTopic: p.topic("command"),
+		PayloadPress: mqttPayloadSync,
+		Icon:         "mdi:refresh",
+	})
+}
+
+// publishDiscoveryConfig Publish the Home Assistant discovery config of a single entity
+func (p *MQTTPublisher) publishDiscoveryConfig(component string, object string, c mqttDiscoveryConfig) {
+	object = mqttObjectID(object)
+	c.UniqueID = fmt.Sprintf("%s_%s", p.clientID, object)
+	c.ObjectID = c.UniqueID
+	c.AvailabilityTopic = p.topic("availability")
+	c.Device = mqttDiscoveryDevice{
+		Identifiers:  []string{p.clientID},
+		Name:         p.clientID,
+		Manufacturer: "ddns",
+		Model:        "ddns",
+		SWVersion:    p.version,
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		log.Error().Msgf("Could not encode mqtt discovery config: %s", err)
+		return
+	}
+	p.publish(fmt.Sprintf("%s/%s/%s/%s/config", p.discoveryPrefix, component, mqttObjectID(p.clientID), object), string(b))
+}
+
+// publish Publish the retained payload to the topic without waiting for the broker, failures are logged in the
+// background so that a slow broker does not delay synchronizations
+func (p *MQTTPublisher) publish(topic string, payload string) mqtt.Token {
+	t := p.client.Publish(topic, 1, true, payload)
+	go func() {
+		if !t.WaitTimeout(mqttPublishTimeout) {
+			log.Error().Msgf("Timed out publishing to mqtt topic %s", topic)
+			return
+		}
+		if err := t.Error(); err != nil {
+			log.Error().Msgf("Could not publish to mqtt topic %s: %s", topic, err)
+		}
+	}()
+	return t
+}
+
+// topic Returns the topic below the topic prefix
+func (p *MQTTPublisher) topic(name string) string {
+	return p.topicPrefix + "/" + name
+}
+
+// mqttObjectID Returns the string with all characters not allowed in discovery topics replaced by underscores
+func mqttObjectID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+type mqttMessages struct {
+	mu       sync.Mutex
+	messages map[string]string
+}
+
+func (m *mqttMessages) get(topic string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.messages[topic]
+	return v, ok
+}
+
+// newTestBroker starts an embedded broker on a free local port and records all published messages
+func newTestBroker(t *testing.T) (string, *mochi.Server, *mqttMessages) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	_ = l.Close()
+
+	server := mochi.New(&mochi.Options{InlineClient: true, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: address})); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+
+	messages := &mqttMessages{messages: map[string]string{}}
+	err = server.Subscribe("#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		messages.mu.Lock()
+		defer messages.mu.Unlock()
+		messages.messages[pk.TopicName] = string(pk.Payload)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "tcp://" + address, server, messages
+}
+
+func waitForMessage(t *testing.T, m *mqttMessages, topic string, wanted string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok := m.get(topic); ok && (wanted == "" || got == wanted) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	got, _ := m.get(topic)
+	t.Fatalf("got %s on topic %s, wanted %s", got, topic, wanted)
+}
+
+func newTestMQTTPublisher(t *testing.T, broker string) (*MQTTPublisher, *Scheduler, chan struct{}) {
+	t.Helper()
+	s, attempts := newTestScheduler(nil)
+	config := *defaultMQTTConfig
+	config.Broker = broker
+	p := NewMQTTPublisher(&config, s, "test")
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Stop)
+	return p, s, attempts
+}
+
+// TestMQTTPublisherState tests that the state and discovery configs are published after a synchronization
+func TestMQTTPublisherState(t *testing.T) {
+	broker, _, messages := newTestBroker(t)
+	_, s, attempts := newTestMQTTPublisher(t, broker)
+
+	waitForMessage(t, messages, "ddns/availability", "online")
+	go s.Run()
+	expectAttempt(t, attempts)
+
+	waitForMessage(t, messages, "ddns/address", "10.0.0.1")
+	waitForMessage(t, messages, "ddns/health", "ok")
+	waitForMessage(t, messages, "ddns/records/example.com", "10.0.0.1")
+	waitForMessage(t, messages, "ddns/records/www.example.com", "10.0.0.1")
+	waitForMessage(t, messages, "ddns/last_sync", "")
+
+	waitForMessage(t, messages, "homeassistant/binary_sensor/ddns/health/config", "")
+	got, _ := messages.get("homeassistant/binary_sensor/ddns/health/config")
+	var c mqttDiscoveryConfig
+	if err := json.Unmarshal([]byte(got), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.StateTopic != "ddns/health" || c.DeviceClass != "problem" || c.PayloadOn != "failing" {
+		t.Errorf("got %s, wanted a problem binary sensor on ddns/health", got)
+	}
+	if c.Device.SWVersion != "test" || c.AvailabilityTopic != "ddns/availability" {
+		t.Errorf("got %s, wanted device version test and availability topic", got)
+	}
+
+	waitForMessage(t, messages, "homeassistant/sensor/ddns/record_www_example_com/config", "")
+	waitForMessage(t, messages, "homeassistant/button/ddns/sync/config", "")
+}
+
+// TestMQTTPublisherCommand tests that a sync command triggers a synchronization
+func TestMQTTPublisherCommand(t *testing.T) {
+	broker, server, messages := newTestBroker(t)
+	_, s, attempts := newTestMQTTPublisher(t, broker)
+
+	waitForMessage(t, messages, "ddns/availability", "online")
+	go s.Run()
+	expectAttempt(t, attempts)
+
+	if err := server.Publish("ddns/command", []byte("sync"), false, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectAttempt(t, attempts)
+}
+
+// stalledMQTTClient An mqtt.Client whose messages are never acknowledged by the broker
+type stalledMQTTClient struct {
+	mqtt.Client
+}
+
+func (stalledMQTTClient) Publish(string, byte, bool, interface{}) mqtt.Token {
+	return stalledMQTTToken{}
+}
+
+type stalledMQTTToken struct{}
+
+func (stalledMQTTToken) Wait() bool                       { select {} }
+func (stalledMQTTToken) WaitTimeout(d time.Duration) bool { time.Sleep(d); return false }
+func (stalledMQTTToken) Done() <-chan struct{}            { return make(chan struct{}) }
+func (stalledMQTTToken) Error() error                     { return nil }
+
+// TestMQTTPublisherStalledBroker tests that publishing the state does not wait for a broker that does not acknowledge
+func TestMQTTPublisherStalledBroker(t *testing.T) {
+	s, _ := newTestScheduler(nil)
+	if err := s.Syncer().Sync(); err != nil {
+		t.Fatal(err)
+	}
+	p := NewMQTTPublisher(defaultMQTTConfig, s, "test")
+	p.client = stalledMQTTClient{}
+
+	start := time.Now()
+	p.PublishState()
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("got %s, wanted publishing not to wait for the broker", d)
+	}
+}
+
+// TestMQTTObjectID tests that characters not allowed in discovery topics are replaced
+func TestMQTTObjectID(t *testing.T) {
+	got := mqttObjectID("record_www.example.com")
+	if wanted := "record_www_example_com"; got != wanted {
+		t.Errorf("got %s, wanted %s", got, wanted)
+	}
+	if strings.ContainsAny(mqttObjectID("a/b+c#"), "/+#") {
+		t.Error("expected topic wildcards to be replaced")
+	}
+}
+
+// TestMQTTPublisherUnreachable tests that starting does not wait for an unreachable broker
+func TestMQTTPublisherUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	_ = l.Close()
+
+	s, _ := newTestScheduler(nil)
+	config := *defaultMQTTConfig
+	config.Broker = "tcp://" + address
+	p := NewMQTTPublisher(&config, s, "test")
+
+	start := time.Now()
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got %s, wanted Start to return without waiting for the broker", elapsed)
+	}
+	if p.client.IsConnectionOpen() {
+		t.Error("wanted the client not to be connected yet")
+	}
+}
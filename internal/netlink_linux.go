@@ -0,0 +1,66 @@
+//go:build linux
+
+package internal
+
+import (
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sys/unix"
+)
+
+// subscribeAddressChanges Returns a channel that receives an event for every rtnetlink address or route change
+func subscribeAddressChanges() (<-chan struct{}, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: unix.RTMGRP_IPV4_IFADDR | unix.RTMGRP_IPV6_IFADDR | unix.RTMGRP_IPV4_ROUTE | unix.RTMGRP_IPV6_ROUTE,
+	}
+	if err := unix.Bind(fd, addr); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	events := make(chan struct{})
+	go func() {
+		defer close(events)
+		defer unix.Close(fd)
+
+		buf := make([]byte, unix.Getpagesize())
+		for {
+			n, _, err := unix.Recvfrom(fd, buf, 0)
+			if err == unix.EINTR {
+				continue
+			} else if err == unix.ENOBUFS {
+				// The socket buffer overflowed during a burst of changes, the dropped messages are covered by a
+				// synchronization
+				log.Warn().Msg("Netlink messages were dropped, synchronizing to catch up")
+				events <- struct{}{}
+				continue
+			} else if err != nil {
+				log.Error().Msgf("Stopped listening for netlink events: %s", err)
+				return
+			}
+
+			msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+			if err != nil {
+				log.Debug().Msgf("Could not parse netlink message: %s", err)
+				continue
+			}
+
+			for _, m := range msgs {
+				switch m.Header.Type {
+				case unix.RTM_NEWADDR, unix.RTM_DELADDR, unix.RTM_NEWROUTE, unix.RTM_DELROUTE:
+					log.Debug().Msgf("Received netlink message of type %d", m.Header.Type)
+					events <- struct{}{}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package internal
+
+import "errors"
+
+// subscribeAddressChanges Netlink is only available on linux
+func subscribeAddressChanges() (<-chan struct{}, error) {
+	return nil, errors.New("netlink address change events are only supported on linux")
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NotificationsConfig Config section governing notifications about address changes and failures
+type NotificationsConfig struct {
+	// Number of consecutive failed synchronizations after which a sync_failed event is sent
+	FailureThreshold int `yaml:"failureThreshold" envconfig:"DDNS_NOTIFY_FAILURE_THRESHOLD" required:"false"`
+
+	// Go duration after which sending a single notification is aborted
+	Timeout time.Duration `yaml:"timeout" envconfig:"DDNS_NOTIFY_TIMEOUT" required:"false"`
+
+	// Config section governing the generic webhook notifier
+	Webhook WebhookNotifierConfig `yaml:"webhook"`
+
+	// Config section governing the email notifier
+	Email EmailNotifierConfig `yaml:"email"`
+
+	// Config section governing the ntfy notifier
+	Ntfy NtfyNotifierConfig `yaml:"ntfy"`
+
+	// Config section governing the Gotify notifier
+	Gotify GotifyNotifierConfig `yaml:"gotify"`
+
+	// Config section governing the Slack notifier
+	Slack SlackNotifierConfig `yaml:"slack"`
+
+	// Config section governing the Discord notifier
+	Discord DiscordNotifierConfig `yaml:"discord"`
+
+	// Config section governing the Matrix notifier
+	Matrix MatrixNotifierConfig `yaml:"matrix"`
+
+	// Config section governing the Telegram notifier
+	Telegram TelegramNotifierConfig `yaml:"telegram"`
+}
+
+var defaultNotificationsConfig = &NotificationsConfig{
+	FailureThreshold: 3,
+	Timeout:          10 * time.Second,
+	Webhook:          *defaultWebhookNotifierConfig,
+	Email:            *defaultEmailNotifierConfig,
+	Ntfy:             *defaultNtfyNotifierConfig,
+	Gotify:           GotifyNotifierConfig{},
+	Slack:            SlackNotifierConfig{},
+	Discord:          DiscordNotifierConfig{},
+	Matrix:           MatrixNotifierConfig{},
+	Telegram:         *defaultTelegramNotifierConfig,
+}
+
+// EventType The kind of an Event
+type EventType string
+
+const (
+	EventAddressChanged EventType = "address_changed"
+	EventRecordUpdated  EventType = "record_updated"
+	EventSyncFailed     EventType = "sync_failed"
+	EventRecovered      EventType = "recovered"
+)
+
+// Event Something that happened during synchronization that notifiers are told about
+type Event struct {
+	Type       EventType `json:"type"`
+	Time       time.Time `json:"time"`
+	Message    string    `json:"message"`
+	OldAddress string    `json:"oldAddress,omitempty"`
+	NewAddress string    `json:"newAddress,omitempty"`
+	ARecord    string    `json:"aRecord,omitempty"`
+	Failures   int       `json:"failures,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Title Returns a short summary of the event
+func (e Event) Title() string {
+	switch e.Type {
+	case EventAddressChanged:
+		return "DDNS: ip address changed"
+	case EventRecordUpdated:
+		return "DDNS: A record updated"
+	case EventSyncFailed:
+		return "DDNS: synchronization failing"
+	case EventRecovered:
+		return "DDNS: synchronization recovered"
+	}
+	return "DDNS"
+}
+
+type Notifier interface {
+	// Notify Send the event to the notification target
+	Notify(context.Context, Event) error
+}
+
+// notifierTarget A Notifier and the event types it is subscribed to
+type notifierTarget struct {
+	name     string
+	notifier Notifier
+	events   map[EventType]bool
+}
+
+// Notifications Dispatches events to all configured notifiers subscribed to them
+type Notifications struct {
+	targets          []notifierTarget
+	failureThreshold int
+	timeout          time.Duration
+}
+
+// NewNotifications Returns an instance of Notifications with all notifiers enabled in the passed configuration
+func NewNotifications(config *NotificationsConfig) *Notifications {
+	n := &Notifications{
+		failureThreshold: config.FailureThreshold,
+		timeout:          config.Timeout,
+	}
+
+	if config.Webhook.Enable {
+		n.Add("webhook", NewWebhookNotifier(&config.Webhook), config.Webhook.Events)
+	}
+	if config.Email.Enable {
+		n.Add("email", NewEmailNotifier(&config.Email), config.Email.Events)
+	}
+	if config.Ntfy.Enable {
+		n.Add("ntfy", NewNtfyNotifier(&config.Ntfy), config.Ntfy.Events)
+	}
+	if config.Gotify.Enable {
+		n.Add("gotify", NewGotifyNotifier(&config.Gotify), config.Gotify.Events)
+	}
+	if config.Slack.Enable {
+		n.Add("slack", NewSlackNotifier(&config.Slack), config.Slack.Events)
+	}
+	if config.Discord.Enable {
+		n.Add("discord", NewDiscordNotifier(&config.Discord), config.Discord.Events)
+	}
+	if config.Matrix.Enable {
+		n.Add("matrix", NewMatrixNotifier(&config.Matrix), config.Matrix.Events)
+	}
+	if config.Telegram.Enable {
+		n.Add("telegram", NewTelegramNotifier(&config.Telegram), config.Telegram.Events)
+	}
+
+	return n
+}
+
+// Add Subscribe the notifier to the event types, or to all event types if none are passed
+func (n *Notifications) Add(name string, notifier Notifier, events []string) {
+	t := notifierTarget{name: name, notifier: notifier, events: map[EventType]bool{}}
+	for _, e := range events {
+		t.events[EventType(e)] = true
+	}
+
+	log.Debug().Msgf("Sending notifications via %s", name)
+	n.targets = append(n.targets, t)
+}
+
+// Dispatch Send the event to all notifiers subscribed to its type, failures are logged
+func (n *Notifications) Dispatch(ctx context.Context, e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	for _, t := range n.targets {
+		if len(t.events) > 0 && !t.events[e.Type] {
+			continue
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, n.timeout)
+		if err := t.notifier.Notify(tctx, e); err != nil {
+			log.Error().Msgf("Could not send %s notification via %s: %s", e.Type, t.name, err)
+			ErrorsCounter.WithLabelValues(errorClassNotification).Inc()
+		}
+		cancel()
+	}
+}
+
+// notificationClient The http client shared by all notifiers
+var notificationClient = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
+// sendNotification Send the request and return an error unless the response status is 2xx
+func sendNotification(ctx context.Context, method string, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := notificationClient.Do(req)
+	if err != nil {
+		// Webhook urls and bot tokens are part of the url and must not end up in logs
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(req.URL)
+		}
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("response status code from notification target was %s", res.Status)
+	}
+
+	return nil
+}
+
+// redactURL Returns the url with everything but the scheme and host redacted
+func redactURL(u *neturl.URL) string {
+	return (&neturl.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + redacted}).String()
+}
+
+// sendJSONNotification Send the payload encoded as json
+func sendJSONNotification(ctx context.Context, method string, url string, payload any, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	h := map[string]string{"Content-Type": "application/json"}
+	for k, v := range headers {
+		h[k] = v
+	}
+
+	return sendNotification(ctx, method, url, body, h)
+}
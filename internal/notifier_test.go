@@ -0,0 +1,297 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	events []Event
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, e Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+type recordedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func newRecordingServer(t *testing.T) (*httptest.Server, *[]recordedRequest) {
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{method: r.Method, path: r.URL.Path, header: r.Header, body: string(b)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+var testEvent = Event{Type: EventRecordUpdated, Message: "A record example.com updated", ARecord: "example.com", NewAddress: "10.0.0.2"}
+
+// TestSyncerNotifications tests that the Syncer dispatches events for address changes, updates, failures and recovery
+func TestSyncerNotifications(t *testing.T) {
+	c := defaultConfig
+	c.NotificationsConfig.FailureThreshold = 2
+	i := &fakeIPAddressProvider{address: "10.0.0.1"}
+	d := newFakeDNSProvider()
+	s := NewSyncer(&c, i, d, false)
+	r := &recordingNotifier{}
+	s.notifications.Add("recording", r, nil)
+
+	_ = s.Sync()
+	i.address = "10.0.0.5"
+	_ = s.Sync()
+	i.err = errors.New("boom")
+	_ = s.Sync()
+	_ = s.Sync()
+	_ = s.Sync()
+	i.err = nil
+	_ = s.Sync()
+
+	var got []EventType
+	for _, e := range r.events {
+		got = append(got, e.Type)
+	}
+	want := []EventType{
+		EventRecordUpdated,
+		EventAddressChanged, EventRecordUpdated, EventRecordUpdated,
+		EventSyncFailed,
+		EventRecovered,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got events %v, wanted %v", got, want)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Fatalf("got events %v, wanted %v", got, want)
+		}
+	}
+
+	if r.events[1].OldAddress != "10.0.0.1" || r.events[1].NewAddress != "10.0.0.5" {
+		t.Errorf("unexpected address changed event %+v", r.events[1])
+	}
+	if r.events[4].Failures != 2 || r.events[4].Error != "boom" {
+		t.Errorf("unexpected sync failed event %+v", r.events[4])
+	}
+}
+
+// TestNotificationsEventFilter tests that notifiers only receive the event types they are subscribed to
+func TestNotificationsEventFilter(t *testing.T) {
+	n := NewNotifications(defaultNotificationsConfig)
+	all, failures := &recordingNotifier{}, &recordingNotifier{}
+	n.Add("all", all, nil)
+	n.Add("failures", failures, []string{string(EventSyncFailed)})
+
+	n.Dispatch(context.Background(), testEvent)
+	n.Dispatch(context.Background(), Event{Type: EventSyncFailed})
+
+	if len(all.events) != 2 || len(failures.events) != 1 || failures.events[0].Type != EventSyncFailed {
+		t.Errorf("got %d and %d events, wanted 2 and 1", len(all.events), len(failures.events))
+	}
+}
+
+// TestWebhookNotifier tests that the webhook body is rendered from the template and signed
+func TestWebhookNotifier(t *testing.T) {
+	srv, requests := newRecordingServer(t)
+	n := NewWebhookNotifier(&WebhookNotifierConfig{
+		URL:      srv.URL + "/hook",
+		Template: `{"record": {{ json .ARecord }}, "type": "{{ .Type }}"}`,
+		Secret:   "secret",
+	})
+
+	if err := n.Notify(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	r := (*requests)[0]
+	want := `{"record": "example.com", "type": "record_updated"}`
+	if r.body != want {
+		t.Errorf("got body %s, wanted %s", r.body, want)
+	}
+	if sig := r.header.Get("X-DDNS-Signature"); sig != "sha256="+SignWebhookBody("secret", []byte(want)) {
+		t.Errorf("unexpected signature %s", sig)
+	}
+}
+
+// TestWebhookNotifierDefaultTemplate tests that the default template posts the event as json
+func TestWebhookNotifierDefaultTemplate(t *testing.T) {
+	srv, requests := newRecordingServer(t)
+	n := NewWebhookNotifier(&WebhookNotifierConfig{URL: srv.URL, Template: defaultWebhookNotifierConfig.Template})
+
+	if err := n.Notify(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	var e Event
+	if err := json.Unmarshal([]byte((*requests)[0].body), &e); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if e.ARecord != "example.com" || (*requests)[0].header.Get("X-DDNS-Signature") != "" {
+		t.Errorf("unexpected request %+v", (*requests)[0])
+	}
+}
+
+// TestChatNotifiers tests the requests sent by the push and chat notifiers
+func TestChatNotifiers(t *testing.T) {
+	srv, requests := newRecordingServer(t)
+
+	notifiers := []struct {
+		notifier Notifier
+		method   string
+		path     string
+		header   string
+		body     string
+	}{
+		{NewNtfyNotifier(&NtfyNotifierConfig{URL: srv.URL, Topic: "ddns", Token: "tk"}), "POST", "/ddns", "Bearer tk", testEvent.Message},
+		{NewGotifyNotifier(&GotifyNotifierConfig{URL: srv.URL, Token: "tk"}), "POST", "/message", "", `"message":"A record example.com updated"`},
+		{NewSlackNotifier(&SlackNotifierConfig{WebhookURL: srv.URL + "/slack"}), "POST", "/slack", "", `{"text":"A record example.com updated"}`},
+		{NewDiscordNotifier(&DiscordNotifierConfig{WebhookURL: srv.URL + "/discord"}), "POST", "/discord", "", `{"content":"A record example.com updated"}`},
+		{NewMatrixNotifier(&MatrixNotifierConfig{Homeserver: srv.URL, AccessToken: "tk", RoomID: "!room:example.com"}), "PUT", "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/", "Bearer tk", `"msgtype":"m.text"`},
+		{NewTelegramNotifier(&TelegramNotifierConfig{APIURL: srv.URL, BotToken: "tk", ChatID: "42"}), "POST", "/bottk/sendMessage", "", `"chat_id":"42"`},
+	}
+
+	for idx, n := range notifiers {
+		if err := n.notifier.Notify(context.Background(), testEvent); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+
+		r := (*requests)[idx]
+		if r.method != n.method || !strings.HasPrefix(r.path, n.path) || !strings.Contains(r.body, n.body) {
+			t.Errorf("unexpected request %+v for %T", r, n.notifier)
+		}
+		if n.header != "" && r.header.Get("Authorization") != n.header {
+			t.Errorf("got authorization %s for %T, wanted %s", r.header.Get("Authorization"), n.notifier, n.header)
+		}
+	}
+
+	if (*requests)[1].header.Get("X-Gotify-Key") != "tk" {
+		t.Error("expected gotify token header")
+	}
+}
+
+// TestNotifierErrorStatus tests that non 2xx responses are reported as errors
+func TestNotifierErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if err := NewSlackNotifier(&SlackNotifierConfig{WebhookURL: srv.URL}).Notify(context.Background(), testEvent); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestEmailNotifier tests that an email is delivered to a local SMTP server
+func TestEmailNotifier(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	defer l.Close()
+
+	data := make(chan string, 1)
+	go serveFakeSMTP(l, data)
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	n := NewEmailNotifier(&EmailNotifierConfig{Host: host, Port: port, From: "ddns@example.com", To: []string{"admin@example.com"}})
+	if err := n.Notify(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	msg := <-data
+	if !strings.Contains(msg, "Subject: DDNS: A record updated") || !strings.Contains(msg, testEvent.Message) {
+		t.Errorf("unexpected message %s", msg)
+	}
+}
+
+// TestEmailNotifierTimeout tests that a hanging SMTP server is disconnected once the context is done
+func TestEmailNotifierTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	defer l.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Never greet, the client has to give up and close the connection
+		_, _ = conn.Read(make([]byte, 1))
+		close(closed)
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	n := NewEmailNotifier(&EmailNotifierConfig{Host: host, Port: port, From: "ddns@example.com", To: []string{"admin@example.com"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.Notify(ctx, testEvent); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, wanted %s", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("expected the connection to be closed")
+	}
+}
+
+// serveFakeSMTP Accepts a single SMTP session and sends the message data on the channel
+func serveFakeSMTP(l net.Listener, data chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+	write("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250 localhost")
+		case cmd == "DATA":
+			write("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			data <- msg.String()
+			write("250 ok")
+		case cmd == "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("250 ok")
+		}
+	}
+}
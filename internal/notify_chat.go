@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SlackNotifierConfig Configuration for the Slack notifier
+type SlackNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_SLACK_ENABLE" required:"false"`
+
+	// Slack incoming webhook url
+	WebhookURL string `yaml:"webhookURL" envconfig:"DDNS_NOTIFY_SLACK_WEBHOOK_URL" required:"false" secret:"true"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_SLACK_EVENTS" required:"false"`
+}
+
+// SlackNotifier Posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+}
+
+// NewSlackNotifier Returns an instance of SlackNotifier based on the passed configuration
+func NewSlackNotifier(config *SlackNotifierConfig) *SlackNotifier {
+	return &SlackNotifier{webhookURL: config.WebhookURL}
+}
+
+// Notify Post the event message to the webhook
+func (n *SlackNotifier) Notify(ctx context.Context, e Event) error {
+	return sendJSONNotification(ctx, "POST", n.webhookURL, map[string]string{"text": e.Message}, nil)
+}
+
+// DiscordNotifierConfig Configuration for the Discord notifier
+type DiscordNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_DISCORD_ENABLE" required:"false"`
+
+	// Discord webhook url
+	WebhookURL string `yaml:"webhookURL" envconfig:"DDNS_NOTIFY_DISCORD_WEBHOOK_URL" required:"false" secret:"true"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_DISCORD_EVENTS" required:"false"`
+}
+
+// DiscordNotifier Posts notifications to a Discord webhook
+type DiscordNotifier struct {
+	webhookURL string
+}
+
+// NewDiscordNotifier Returns an instance of DiscordNotifier based on the passed configuration
+func NewDiscordNotifier(config *DiscordNotifierConfig) *DiscordNotifier {
+	return &DiscordNotifier{webhookURL: config.WebhookURL}
+}
+
+// Notify Post the event message to the webhook
+func (n *DiscordNotifier) Notify(ctx context.Context, e Event) error {
+	return sendJSONNotification(ctx, "POST", n.webhookURL, map[string]string{"content": e.Message}, nil)
+}
+
+// MatrixNotifierConfig Configuration for the Matrix notifier
+type MatrixNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_MATRIX_ENABLE" required:"false"`
+
+	// Base url of the homeserver
+	Homeserver string `yaml:"homeserver" envconfig:"DDNS_NOTIFY_MATRIX_HOMESERVER" required:"false"`
+
+	// Access token of the user sending the messages
+	AccessToken string `yaml:"accessToken" envconfig:"DDNS_NOTIFY_MATRIX_ACCESS_TOKEN" required:"false" secret:"true"`
+
+	// Id of the room to send the messages to
+	RoomID string `yaml:"roomID" envconfig:"DDNS_NOTIFY_MATRIX_ROOM_ID" required:"false"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_MATRIX_EVENTS" required:"false"`
+}
+
+// MatrixNotifier Sends notifications as messages to a Matrix room
+type MatrixNotifier struct {
+	homeserver  string
+	accessToken string
+	roomID      string
+}
+
+// NewMatrixNotifier Returns an instance of MatrixNotifier based on the passed configuration
+func NewMatrixNotifier(config *MatrixNotifierConfig) *MatrixNotifier {
+	return &MatrixNotifier{
+		homeserver:  strings.TrimSuffix(config.Homeserver, "/"),
+		accessToken: config.AccessToken,
+		roomID:      config.RoomID,
+	}
+}
+
+// Notify Send the event message to the room
+func (n *MatrixNotifier) Notify(ctx context.Context, e Event) error {
+	requestURL := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/ddns-%d",
+		n.homeserver, url.PathEscape(n.roomID), e.Time.UnixNano())
+	payload := map[string]string{"msgtype": "m.text", "body": e.Message}
+
+	return sendJSONNotification(ctx, "PUT", requestURL, payload, map[string]string{"Authorization": "Bearer " + n.accessToken})
+}
+
+// TelegramNotifierConfig Configuration for the Telegram notifier
+type TelegramNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_TELEGRAM_ENABLE" required:"false"`
+
+	// Base url of the Telegram bot api
+	APIURL string `yaml:"apiURL" envconfig:"DDNS_NOTIFY_TELEGRAM_API_URL" required:"false"`
+
+	// Token of the bot sending the messages
+	BotToken string `yaml:"botToken" envconfig:"DDNS_NOTIFY_TELEGRAM_BOT_TOKEN" required:"false" secret:"true"`
+
+	// Id of the chat to send the messages to
+	ChatID string `yaml:"chatID" envconfig:"DDNS_NOTIFY_TELEGRAM_CHAT_ID" required:"false"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_TELEGRAM_EVENTS" required:"false"`
+}
+
+var defaultTelegramNotifierConfig = &TelegramNotifierConfig{
+	Enable: false,
+	APIURL: "https://api.telegram.org",
+}
+
+// TelegramNotifier Sends notifications as messages of a Telegram bot
+type TelegramNotifier struct {
+	apiURL   string
+	botToken string
+	chatID   string
+}
+
+// NewTelegramNotifier Returns an instance of TelegramNotifier based on the passed configuration
+func NewTelegramNotifier(config *TelegramNotifierConfig) *TelegramNotifier {
+	return &TelegramNotifier{
+		apiURL:   strings.TrimSuffix(config.APIURL, "/"),
+		botToken: config.BotToken,
+		chatID:   config.ChatID,
+	}
+}
+
+// Notify Send the event message to the chat
+func (n *TelegramNotifier) Notify(ctx context.Context, e Event) error {
+	requestURL := fmt.Sprintf("%s/bot%s/sendMessage", n.apiURL, n.botToken)
+	return sendJSONNotification(ctx, "POST", requestURL, map[string]string{"chat_id": n.chatID, "text": e.Message}, nil)
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifierConfig Configuration for the email notifier
+type EmailNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_EMAIL_ENABLE" required:"false"`
+
+	// Host of the SMTP server
+	Host string `yaml:"host" envconfig:"DDNS_NOTIFY_EMAIL_HOST" required:"false"`
+
+	// Port of the SMTP server, STARTTLS is used if the server supports it
+	Port string `yaml:"port" envconfig:"DDNS_NOTIFY_EMAIL_PORT" required:"false"`
+
+	// SMTP username, only set if required
+	Username string `yaml:"username" envconfig:"DDNS_NOTIFY_EMAIL_USERNAME" required:"false"`
+
+	// SMTP password, only set if required
+	Password string `yaml:"password" envconfig:"DDNS_NOTIFY_EMAIL_PASSWORD" required:"false" secret:"true"`
+
+	// Sender address
+	From string `yaml:"from" envconfig:"DDNS_NOTIFY_EMAIL_FROM" required:"false"`
+
+	// List of recipient addresses
+	To StringList `yaml:"to" envconfig:"DDNS_NOTIFY_EMAIL_TO" required:"false"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_EMAIL_EVENTS" required:"false"`
+}
+
+var defaultEmailNotifierConfig = &EmailNotifierConfig{
+	Enable: false,
+	Port:   "587",
+}
+
+// EmailNotifier Sends notifications as plain text email via SMTP
+type EmailNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewEmailNotifier Returns an instance of EmailNotifier based on the passed configuration
+func NewEmailNotifier(config *EmailNotifierConfig) *EmailNotifier {
+	return &EmailNotifier{
+		host:     config.Host,
+		port:     config.Port,
+		username: config.Username,
+		password: config.Password,
+		from:     config.From,
+		to:       config.To,
+	}
+}
+
+// Notify Send the event as email to all recipients
+func (n *EmailNotifier) Notify(ctx context.Context, e Event) error {
+	var auth smtp.Auth
+	if n.username != "" && n.password != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		n.from, strings.Join(n.to, ", "), e.Title(), e.Time.Format(time.RFC1123Z), e.Message)
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(n.host, n.port))
+	if err != nil {
+		return err
+	}
+
+	// The SMTP client does not accept a context, closing the connection aborts a hanging conversation
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := n.send(conn, auth, []byte(msg)); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// send Deliver the message over the connection like smtp.SendMail, using STARTTLS if the server supports it
+func (n *EmailNotifier) send(conn net.Conn, auth smtp.Auth, msg []byte) error {
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support authentication")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"context"
+	"strings"
+)
+
+// NtfyNotifierConfig Configuration for the ntfy notifier
+type NtfyNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_NTFY_ENABLE" required:"false"`
+
+	// Base url of the ntfy server
+	URL string `yaml:"url" envconfig:"DDNS_NOTIFY_NTFY_URL" required:"false"`
+
+	// Topic to publish to
+	Topic string `yaml:"topic" envconfig:"DDNS_NOTIFY_NTFY_TOPIC" required:"false"`
+
+	// Access token, only set if required
+	Token string `yaml:"token" envconfig:"DDNS_NOTIFY_NTFY_TOKEN" required:"false" secret:"true"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_NTFY_EVENTS" required:"false"`
+}
+
+var defaultNtfyNotifierConfig = &NtfyNotifierConfig{
+	Enable: false,
+	URL:    "https://ntfy.sh",
+}
+
+// NtfyNotifier Publishes notifications to a ntfy topic
+type NtfyNotifier struct {
+	url   string
+	topic string
+	token string
+}
+
+// NewNtfyNotifier Returns an instance of NtfyNotifier based on the passed configuration
+func NewNtfyNotifier(config *NtfyNotifierConfig) *NtfyNotifier {
+	return &NtfyNotifier{
+		url:   strings.TrimSuffix(config.URL, "/"),
+		topic: config.Topic,
+		token: config.Token,
+	}
+}
+
+// Notify Publish the event message to the topic
+func (n *NtfyNotifier) Notify(ctx context.Context, e Event) error {
+	headers := map[string]string{"Title": e.Title(), "Tags": string(e.Type)}
+	if e.Type == EventSyncFailed {
+		headers["Priority"] = "high"
+	}
+	if n.token != "" {
+		headers["Authorization"] = "Bearer " + n.token
+	}
+
+	return sendNotification(ctx, "POST", n.url+"/"+n.topic, []byte(e.Message), headers)
+}
+
+// GotifyNotifierConfig Configuration for the Gotify notifier
+type GotifyNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_GOTIFY_ENABLE" required:"false"`
+
+	// Base url of the Gotify server
+	URL string `yaml:"url" envconfig:"DDNS_NOTIFY_GOTIFY_URL" required:"false"`
+
+	// Gotify application token
+	Token string `yaml:"token" envconfig:"DDNS_NOTIFY_GOTIFY_TOKEN" required:"false" secret:"true"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_GOTIFY_EVENTS" required:"false"`
+}
+
+type gotifyMessagePayload struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// GotifyNotifier Sends notifications as Gotify messages
+type GotifyNotifier struct {
+	url   string
+	token string
+}
+
+// NewGotifyNotifier Returns an instance of GotifyNotifier based on the passed configuration
+func NewGotifyNotifier(config *GotifyNotifierConfig) *GotifyNotifier {
+	return &GotifyNotifier{
+		url:   strings.TrimSuffix(config.URL, "/"),
+		token: config.Token,
+	}
+}
+
+// Notify Create a message for the event
+func (n *GotifyNotifier) Notify(ctx context.Context, e Event) error {
+	payload := &gotifyMessagePayload{Title: e.Title(), Message: e.Message, Priority: 5}
+	if e.Type == EventSyncFailed {
+		payload.Priority = 8
+	}
+
+	return sendJSONNotification(ctx, "POST", n.url+"/message", payload, map[string]string{"X-Gotify-Key": n.token})
+}
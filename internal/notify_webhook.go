@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"text/template"
+)
+
+// WebhookNotifierConfig Configuration for the generic webhook notifier
+type WebhookNotifierConfig struct {
+	// Switch to enable or disable this notifier
+	Enable bool `yaml:"enable" envconfig:"DDNS_NOTIFY_WEBHOOK_ENABLE" required:"false"`
+
+	// URL the notifications are posted to
+	URL string `yaml:"url" envconfig:"DDNS_NOTIFY_WEBHOOK_URL" required:"false"`
+
+	// Go text/template rendering the json body, the event is passed as dot
+	Template string `yaml:"template" envconfig:"DDNS_NOTIFY_WEBHOOK_TEMPLATE" required:"false"`
+
+	// Secret used to sign the body with HMAC-SHA256, the signature is sent in the X-DDNS-Signature header
+	Secret string `yaml:"secret" envconfig:"DDNS_NOTIFY_WEBHOOK_SECRET" required:"false" secret:"true"`
+
+	// List of event types to notify about, all if empty
+	Events StringList `yaml:"events" envconfig:"DDNS_NOTIFY_WEBHOOK_EVENTS" required:"false"`
+}
+
+var defaultWebhookNotifierConfig = &WebhookNotifierConfig{
+	Enable:   false,
+	URL:      "",
+	Template: "{{ json . }}",
+	Secret:   "",
+	Events:   nil,
+}
+
+// WebhookNotifier Posts a templated json body to a url
+type WebhookNotifier struct {
+	url      string
+	template string
+	secret   string
+}
+
+// NewWebhookNotifier Returns an instance of WebhookNotifier based on the passed configuration
+func NewWebhookNotifier(config *WebhookNotifierConfig) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:      config.URL,
+		template: config.Template,
+		secret:   config.Secret,
+	}
+}
+
+// Notify Render the template with the event and post it to the url
+func (w *WebhookNotifier) Notify(ctx context.Context, e Event) error {
+	body, err := renderWebhookTemplate(w.template, e)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	if w.secret != "" {
+		headers["X-DDNS-Signature"] = "sha256=" + SignWebhookBody(w.secret, body)
+	}
+
+	return sendNotification(ctx, "POST", w.url, body, headers)
+}
+
+// SignWebhookBody Returns the hex encoded HMAC-SHA256 of the body using the secret
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseWebhookTemplate Parse the template, a json function is available to encode values
+func parseWebhookTemplate(tmpl string) (*template.Template, error) {
+	return template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(tmpl)
+}
+
+// renderWebhookTemplate Render the template with the event
+func renderWebhookTemplate(tmpl string, e Event) ([]byte, error) {
+	t, err := parseWebhookTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// oauthTokenExpiryMargin Go duration before its expiry after which a cached access token is renewed
+const oauthTokenExpiryMargin = time.Minute
+
+// oauthTokenSource Obtains access tokens from an OAuth 2.0 token endpoint and caches them until shortly before they expire
+type oauthTokenSource struct {
+	client   *http.Client
+	tokenURL string
+
+	// form Returns the form posted to the token endpoint, called for every token request
+	form func() (url.Values, error)
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token Returns a cached access token or requests a new one
+func (s *oauthTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && time.Now().Before(s.expiry.Add(-oauthTokenExpiryMargin)) {
+		return s.accessToken, nil
+	}
+
+	form, err := s.form()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	var t oauthTokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&t); err != nil && res.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("could not decode the token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK || t.AccessToken == "" {
+		if t.Error != "" {
+			return "", fmt.Errorf("token request failed with %s: %s", t.Error, t.ErrorDescription)
+		}
+		return "", fmt.Errorf("response status code of the token request was %s, not 200", res.Status)
+	}
+
+	log.Debug().Msgf("Obtained an access token from %s valid for %ds", s.tokenURL, t.ExpiresIn)
+	s.accessToken = t.AccessToken
+	s.expiry = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
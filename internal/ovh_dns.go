@@ -0,0 +1,254 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// OVHDNSProviderConfig Configuration for the OVHcloud DNS Provider
+type OVHDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_OVH_PROVIDER_ENABLE" required:"false"`
+
+	// Url of the OVHcloud API of the region the account belongs to
+	BaseURL string `yaml:"baseURL" envconfig:"DDNS_OVH_PROVIDER_BASE_URL" required:"false"`
+
+	// Application key
+	ApplicationKey string `yaml:"applicationKey" envconfig:"DDNS_OVH_PROVIDER_APPLICATION_KEY" required:"false"`
+
+	// Application secret
+	ApplicationSecret string `yaml:"applicationSecret" envconfig:"DDNS_OVH_PROVIDER_APPLICATION_SECRET" required:"false" secret:"true"`
+
+	// Consumer key granted access to the zone
+	ConsumerKey string `yaml:"consumerKey" envconfig:"DDNS_OVH_PROVIDER_CONSUMER_KEY" required:"false" secret:"true"`
+
+	// Zone the A records belong to
+	Zone string `yaml:"zone" envconfig:"DDNS_OVH_PROVIDER_ZONE" required:"false"`
+
+	// TTL of the A records in seconds, the zone default is used if 0
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_OVH_PROVIDER_TTL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_OVH_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultOVHDNSProviderConfig = &OVHDNSProviderConfig{
+	Enable:            false,
+	BaseURL:           "https://eu.api.ovh.com/1.0",
+	ApplicationKey:    "",
+	ApplicationSecret: "",
+	ConsumerKey:       "",
+	Zone:              "",
+	TTL:               0,
+	ARecords:          nil,
+}
+
+type ovhRecord struct {
+	ID        int64  `json:"id,omitempty"`
+	FieldType string `json:"fieldType,omitempty"`
+	SubDomain string `json:"subDomain"`
+	Target    string `json:"target"`
+	TTL       int64  `json:"ttl"`
+}
+
+// OVHDNSProvider OVHcloud DNS Provider
+type OVHDNSProvider struct {
+	baseURL           string
+	applicationKey    string
+	applicationSecret string
+	consumerKey       string
+	zone              string
+	ttl               int64
+	aRecords          []string
+	client            *http.Client
+
+	mu sync.Mutex
+
+	// timeDelta Difference between the clock of the API and the local clock, requests are rejected if they differ
+	timeDelta *time.Duration
+}
+
+// NewOVHDNSProvider Returns an instance of OVHDNSProvider based on the passed configuration
+func NewOVHDNSProvider(config *OVHDNSProviderConfig) *OVHDNSProvider {
+	return &OVHDNSProvider{
+		baseURL:           strings.TrimSuffix(config.BaseURL, "/"),
+		applicationKey:    config.ApplicationKey,
+		applicationSecret: config.ApplicationSecret,
+		consumerKey:       config.ConsumerKey,
+		zone:              strings.TrimSuffix(config.Zone, "."),
+		ttl:               config.TTL,
+		aRecords:          config.ARecords,
+		client:            &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the id and ip address are empty if a name has no A record yet
+func (o *OVHDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range o.aRecords {
+		q := url.Values{}
+		q.Set("fieldType", "A")
+		q.Set("subDomain", o.subDomain(name))
+
+		var ids []int64
+		if err := o.do(ctx, "GET", o.zonePath()+"/record?"+q.Encode(), nil, &ids); err != nil {
+			return nil, fmt.Errorf("could not list A records of %s: %w", name, err)
+		}
+
+		m := RecordAddressMapping{ARecord: name}
+		if len(ids) > 0 {
+			var r ovhRecord
+			if err := o.do(ctx, "GET", o.zonePath()+"/record/"+strconv.FormatInt(ids[0], 10), nil, &r); err != nil {
+				return nil, fmt.Errorf("could not get A record %s: %w", name, err)
+			}
+			m.ID = strconv.FormatInt(r.ID, 10)
+			m.IPAddress = r.Target
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Set the A record to the provided ip address, the record is created if it does not exist yet.
+// The zone is refreshed afterwards to apply the change.
+func (o *OVHDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	record := ovhRecord{SubDomain: o.subDomain(m.ARecord), Target: ipAddress, TTL: o.ttl}
+	if m.ID == "" {
+		record.FieldType = "A"
+		if err := o.do(ctx, "POST", o.zonePath()+"/record", record, nil); err != nil {
+			return fmt.Errorf("could not create A record %s: %w", m.ARecord, err)
+		}
+	} else if err := o.do(ctx, "PUT", o.zonePath()+"/record/"+url.PathEscape(m.ID), record, nil); err != nil {
+		return fmt.Errorf("could not update A record %s: %w", m.ARecord, err)
+	}
+
+	if err := o.do(ctx, "POST", o.zonePath()+"/refresh", nil, nil); err != nil {
+		return fmt.Errorf("could not refresh zone %s: %w", o.zone, err)
+	}
+
+	return nil
+}
+
+func (o *OVHDNSProvider) zonePath() string {
+	return "/domain/zone/" + url.PathEscape(o.zone)
+}
+
+// subDomain Returns the name relative to the zone, empty for the apex
+func (o *OVHDNSProvider) subDomain(name string) string {
+	if s := relativeRecordName(name, o.zone); s != "@" {
+		return s
+	}
+	return ""
+}
+
+// now Returns the current time of the API clock, the difference to the local clock is requested once
+func (o *OVHDNSProvider) now(ctx context.Context) (time.Time, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.timeDelta == nil {
+		req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/auth/time", nil)
+		if err != nil {
+			return time.Time{}, err
+		}
+		res, err := o.client.Do(req)
+		if err != nil {
+			return time.Time{}, err
+		}
+		defer res.Body.Close()
+
+		var serverTime int64
+		if res.StatusCode != http.StatusOK {
+			return time.Time{}, fmt.Errorf("response status code from /auth/time was %s, not 200", res.Status)
+		}
+		if err := json.NewDecoder(res.Body).Decode(&serverTime); err != nil {
+			return time.Time{}, fmt.Errorf("could not decode the time of the API: %w", err)
+		}
+		delta := time.Until(time.Unix(serverTime, 0))
+		o.timeDelta = &delta
+	}
+
+	return time.Now().Add(*o.timeDelta), nil
+}
+
+// do Send a signed request with the json encoded payload to the API and decode the json response into v if not nil
+func (o *OVHDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) error {
+	var b []byte
+	if payload != nil {
+		var err error
+		if b, err = json.Marshal(payload); err != nil {
+			return err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+	}
+
+	now, err := o.now(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get the time of the API: %w", err)
+	}
+
+	requestURL := o.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Ovh-Application", o.applicationKey)
+	req.Header.Add("X-Ovh-Consumer", o.consumerKey)
+	req.Header.Add("X-Ovh-Timestamp", timestamp)
+	req.Header.Add("X-Ovh-Signature", ovhSignature(o.applicationSecret, o.consumerKey, method, requestURL, string(b), timestamp))
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Message != "" {
+			return fmt.Errorf("response status code from %s was %s: %s", path, res.Status, e.Message)
+		}
+		return fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// ovhSignature Returns the signature of a request as expected in the X-Ovh-Signature header
+func ovhSignature(applicationSecret, consumerKey, method, requestURL, body, timestamp string) string {
+	h := sha1.Sum([]byte(strings.Join([]string{applicationSecret, consumerKey, method, requestURL, body, timestamp}, "+")))
+	return "$1$" + hex.EncodeToString(h[:])
+}
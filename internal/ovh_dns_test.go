@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOVH An in-process stand-in for the OVHcloud zone record API verifying request signatures, its clock runs an hour ahead
+type fakeOVH struct {
+	mu        sync.Mutex
+	url       string
+	records   []ovhRecord
+	refreshes int
+}
+
+func (f *fakeOVH) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now().Add(time.Hour)
+	if r.URL.Path == "/auth/time" {
+		_, _ = fmt.Fprint(w, now.Unix())
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-Ovh-Timestamp"), 10, 64)
+	signature := ovhSignature("app-secret", r.Header.Get("X-Ovh-Consumer"), r.Method, f.url+r.URL.RequestURI(), string(body), r.Header.Get("X-Ovh-Timestamp"))
+	if r.Header.Get("X-Ovh-Application") != "app-key" || r.Header.Get("X-Ovh-Consumer") != "consumer-key" ||
+		r.Header.Get("X-Ovh-Signature") != signature || now.Unix()-timestamp > 5 {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "Invalid signature"})
+		return
+	}
+
+	const zonePath = "/domain/zone/example.com"
+	switch {
+	case r.Method == "GET" && r.URL.Path == zonePath+"/record":
+		ids := []int64{}
+		for _, record := range f.records {
+			if record.FieldType == r.URL.Query().Get("fieldType") && record.SubDomain == r.URL.Query().Get("subDomain") {
+				ids = append(ids, record.ID)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(ids)
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, zonePath+"/record/"):
+		for _, record := range f.records {
+			if r.URL.Path == fmt.Sprintf("%s/record/%d", zonePath, record.ID) {
+				_ = json.NewEncoder(w).Encode(record)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, zonePath+"/record/"):
+		var update ovhRecord
+		_ = json.Unmarshal(body, &update)
+		for i, record := range f.records {
+			if r.URL.Path == fmt.Sprintf("%s/record/%d", zonePath, record.ID) {
+				f.records[i].Target = update.Target
+				f.records[i].TTL = update.TTL
+				_, _ = fmt.Fprint(w, "null")
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "POST" && r.URL.Path == zonePath+"/record":
+		var record ovhRecord
+		_ = json.Unmarshal(body, &record)
+		record.ID = int64(len(f.records) + 1)
+		f.records = append(f.records, record)
+		_ = json.NewEncoder(w).Encode(record)
+	case r.Method == "POST" && r.URL.Path == zonePath+"/refresh":
+		f.refreshes++
+		_, _ = fmt.Fprint(w, "null")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestOVHDNSProvider tests that signed requests with the API clock update and create records and refresh the zone
+func TestOVHDNSProvider(t *testing.T) {
+	f := &fakeOVH{records: []ovhRecord{
+		{ID: 1, FieldType: "NS", SubDomain: "", Target: "dns1.ovh.net."},
+		{ID: 2, FieldType: "A", SubDomain: "", Target: "10.0.0.1"},
+		{ID: 3, FieldType: "A", SubDomain: "www", Target: "10.0.0.2"},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+	f.url = s.URL
+
+	c := *defaultOVHDNSProviderConfig
+	c.BaseURL = s.URL
+	c.ApplicationKey = "app-key"
+	c.ApplicationSecret = "app-secret"
+	c.ConsumerKey = "consumer-key"
+	c.Zone = "example.com"
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewOVHDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecordAddressMapping{
+		{ID: "2", ARecord: "example.com", IPAddress: "10.0.0.1"},
+		{ID: "3", ARecord: "www.example.com", IPAddress: "10.0.0.2"},
+		{ID: "", ARecord: "new.example.com", IPAddress: ""},
+	}
+	for i, m := range ms {
+		if m != want[i] {
+			t.Errorf("got %v, wanted %v", m, want[i])
+		}
+	}
+
+	for _, m := range ms[1:] {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ms, err = p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if m.IPAddress != "10.0.0.1" || m.ID == "" {
+			t.Errorf("got %v, wanted %s with an id", m, "10.0.0.1")
+		}
+	}
+	if f.refreshes != 2 {
+		t.Errorf("got %d refreshes, wanted 2", f.refreshes)
+	}
+
+	p.applicationSecret = "wrong"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil || !strings.Contains(err.Error(), "Invalid signature") {
+		t.Errorf("got %v, wanted the error of the API", err)
+	}
+}
@@ -0,0 +1,282 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// PiHoleDNSProviderConfig Configuration for the Pi-hole local DNS records Provider
+type PiHoleDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_PIHOLE_PROVIDER_ENABLE" required:"false"`
+
+	// Url of the Pi-hole web interface, without the /api path
+	URL string `yaml:"url" envconfig:"DDNS_PIHOLE_PROVIDER_URL" required:"false"`
+
+	// Web interface or app password, empty if the Pi-hole has no password
+	Password string `yaml:"password" envconfig:"DDNS_PIHOLE_PROVIDER_PASSWORD" required:"false" secret:"true"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_PIHOLE_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultPiHoleDNSProviderConfig = &PiHoleDNSProviderConfig{
+	Enable:   false,
+	URL:      "http://pi.hole",
+	Password: "",
+	ARecords: nil,
+}
+
+type piHoleAuthResponse struct {
+	Session struct {
+		Valid   bool   `json:"valid"`
+		SID     string `json:"sid"`
+		Message string `json:"message"`
+	} `json:"session"`
+}
+
+type piHoleHostsResponse struct {
+	Config struct {
+		DNS struct {
+			Hosts []string `json:"hosts"`
+		} `json:"dns"`
+	} `json:"config"`
+}
+
+type piHoleErrorResponse struct {
+	Error struct {
+		Key     string `json:"key"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// PiHoleDNSProvider Pi-hole local DNS records Provider
+type PiHoleDNSProvider struct {
+	url      string
+	password string
+	aRecords []string
+	client   *http.Client
+
+	mu sync.Mutex
+
+	// sid Id of the current session, reused until the API rejects it
+	sid string
+}
+
+// NewPiHoleDNSProvider Returns an instance of PiHoleDNSProvider based on the passed configuration
+func NewPiHoleDNSProvider(config *PiHoleDNSProviderConfig) *PiHoleDNSProvider {
+	return &PiHoleDNSProvider{
+		url:      strings.TrimSuffix(config.URL, "/"),
+		password: config.Password,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the id is the local DNS record holding the name and both are empty if a name has no local DNS record yet
+func (p *PiHoleDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	hosts, err := p.hosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range p.aRecords {
+		m := RecordAddressMapping{ARecord: name}
+		if host, ip := findPiHoleHost(hosts, name); host != "" {
+			m.ID = host
+			m.IPAddress = ip
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Add a local DNS record for the name with the provided ip address and remove the previous one. Other
+// names of the previous record keep their ip address. The previous record is looked up again since setting another name
+// of it replaces it.
+func (p *PiHoleDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	hosts, err := p.hosts(ctx)
+	if err != nil {
+		return err
+	}
+	previous, _ := findPiHoleHost(hosts, m.ARecord)
+
+	if err := p.do(ctx, "PUT", piHoleHostPath(ipAddress+" "+m.ARecord), nil); err != nil {
+		return fmt.Errorf("could not add local DNS record %s: %w", m.ARecord, err)
+	}
+
+	if previous == "" {
+		return nil
+	}
+
+	ip, names := parsePiHoleHost(previous)
+	var others []string
+	for _, name := range names {
+		if !strings.EqualFold(name, m.ARecord) {
+			others = append(others, name)
+		}
+	}
+	if len(others) > 0 {
+		if err := p.do(ctx, "PUT", piHoleHostPath(ip+" "+strings.Join(others, " ")), nil); err != nil {
+			return fmt.Errorf("could not keep local DNS record %s: %w", strings.Join(others, ","), err)
+		}
+	}
+
+	if err := p.do(ctx, "DELETE", piHoleHostPath(previous), nil); err != nil {
+		return fmt.Errorf("could not remove local DNS record %s: %w", previous, err)
+	}
+
+	return nil
+}
+
+// hosts Returns the local DNS records in hosts file format
+func (p *PiHoleDNSProvider) hosts(ctx context.Context) ([]string, error) {
+	var r piHoleHostsResponse
+	if err := p.do(ctx, "GET", "/api/config/dns/hosts", &r); err != nil {
+		return nil, fmt.Errorf("could not list local DNS records: %w", err)
+	}
+	return r.Config.DNS.Hosts, nil
+}
+
+// findPiHoleHost Returns the first local DNS record holding the name with an IPv4 address and that address, both are
+// empty if there is none
+func findPiHoleHost(hosts []string, name string) (string, string) {
+	for _, host := range hosts {
+		if ip, names := parsePiHoleHost(host); ip != "" && containsFold(names, name) {
+			return host, ip
+		}
+	}
+	return "", ""
+}
+
+// parsePiHoleHost Returns the ip address and names of a local DNS record in hosts file format, the ip address is empty if
+// it is not an IPv4 address
+func parsePiHoleHost(host string) (string, []string) {
+	fields := strings.Fields(host)
+	if len(fields) < 2 || net.ParseIP(fields[0]).To4() == nil {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+func piHoleHostPath(host string) string {
+	return "/api/config/dns/hosts/" + url.PathEscape(host)
+}
+
+// containsFold Returns true if the values contain the name, ignoring case
+func containsFold(values []string, name string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// session Returns the id of the current session, a new session is created if there is none yet or renew is set
+func (p *PiHoleDNSProvider) session(ctx context.Context, renew bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sid != "" && !renew {
+		return p.sid, nil
+	}
+
+	// The password is not logged
+	b, err := json.Marshal(map[string]string{"password": p.password})
+	if err != nil {
+		return "", err
+	}
+
+	var r piHoleAuthResponse
+	if _, err := p.request(ctx, "POST", "/api/auth", "", b, &r); err != nil {
+		return "", fmt.Errorf("could not authenticate: %w", err)
+	}
+	if !r.Session.Valid {
+		return "", fmt.Errorf("could not authenticate: %s", r.Session.Message)
+	}
+
+	p.sid = r.Session.SID
+	return p.sid, nil
+}
+
+// do Send an authenticated request to the API and decode the json response into v if not nil, the session is renewed once
+// if it expired
+func (p *PiHoleDNSProvider) do(ctx context.Context, method string, path string, v any) error {
+	sid, err := p.session(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	status, err := p.request(ctx, method, path, sid, nil, v)
+	if status == http.StatusUnauthorized {
+		log.Debug().Msg("Pi-hole session expired, authenticating again")
+		if sid, err = p.session(ctx, true); err != nil {
+			return err
+		}
+		_, err = p.request(ctx, method, path, sid, nil, v)
+	}
+	return err
+}
+
+// request Send a request with the payload to the API and decode the json response into v if not nil, the status code is
+// returned alongside errors
+func (p *PiHoleDNSProvider) request(ctx context.Context, method string, path string, sid string, payload []byte, v any) (int, error) {
+	log.Debug().Msgf("Executing %s request against %s", method, path)
+
+	req, err := http.NewRequestWithContext(ctx, method, p.url+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	if sid != "" {
+		req.Header.Add("X-FTL-SID", sid)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	// Records are added with 201 and removed with 204
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNoContent:
+		return res.StatusCode, nil
+	default:
+		var e piHoleErrorResponse
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Error.Message != "" {
+			return res.StatusCode, fmt.Errorf("response status code from %s was %s: %s", path, res.Status, e.Error.Message)
+		}
+		return res.StatusCode, fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePiHole An in-process stand-in for the Pi-hole v6 auth and local DNS records API
+type fakePiHole struct {
+	mu       sync.Mutex
+	sid      string
+	sessions int
+	hosts    []string
+}
+
+func (f *fakePiHole) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/api/auth" {
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]any{"session": map[string]any{"valid": false, "message": "password incorrect"}})
+			return
+		}
+		f.sessions++
+		f.sid = "sid" + strings.Repeat("x", f.sessions)
+		_ = json.NewEncoder(w).Encode(map[string]any{"session": map[string]any{"valid": true, "sid": f.sid, "validity": 1800}})
+		return
+	}
+
+	if r.Header.Get("X-FTL-SID") != f.sid {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"key": "unauthorized", "message": "Unauthorized"}})
+		return
+	}
+
+	const hostsPath = "/api/config/dns/hosts"
+	host := strings.TrimPrefix(r.URL.Path, hostsPath+"/")
+	switch {
+	case r.Method == "GET" && r.URL.Path == hostsPath:
+		_ = json.NewEncoder(w).Encode(map[string]any{"config": map[string]any{"dns": map[string]any{"hosts": f.hosts}}})
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, hostsPath+"/"):
+		for _, h := range f.hosts {
+			if h == host {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"key": "bad_request", "message": "Item already present"}})
+				return
+			}
+		}
+		f.hosts = append(f.hosts, host)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"config": map[string]any{}})
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, hostsPath+"/"):
+		for i, h := range f.hosts {
+			if h == host {
+				f.hosts = append(f.hosts[:i], f.hosts[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestPiHoleDNSProvider tests that local DNS records are replaced, other names of a record are kept and expired sessions
+// are renewed
+func TestPiHoleDNSProvider(t *testing.T) {
+	f := &fakePiHole{hosts: []string{"10.0.0.1 nas.lan", "10.0.0.2 home.lan media.lan", "fd00::1 home.lan"}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultPiHoleDNSProviderConfig
+	c.URL = s.URL
+	c.Password = "secret"
+	c.ARecords = StringList{"home.lan", "new.lan"}
+	p := NewPiHoleDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecordAddressMapping{
+		{ID: "10.0.0.2 home.lan media.lan", ARecord: "home.lan", IPAddress: "10.0.0.2"},
+		{ID: "", ARecord: "new.lan", IPAddress: ""},
+	}
+	for i, m := range ms {
+		if m != want[i] {
+			t.Errorf("got %v, wanted %v", m, want[i])
+		}
+	}
+
+	// Expire the session
+	f.sid = "expired"
+
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.3", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantHosts := "10.0.0.1 nas.lan,fd00::1 home.lan,10.0.0.3 home.lan,10.0.0.2 media.lan,10.0.0.3 new.lan"
+	if got := strings.Join(f.hosts, ","); got != wantHosts {
+		t.Errorf("got %s, wanted %s", got, wantHosts)
+	}
+	if f.sessions != 2 {
+		t.Errorf("got %d sessions, wanted 2", f.sessions)
+	}
+
+	p.password = "wrong"
+	f.sid = "expired"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil || !strings.Contains(err.Error(), "could not authenticate") {
+		t.Errorf("got %v, wanted an authentication error", err)
+	}
+}
+
+// TestPiHoleDNSProviderSharedRecord tests that two managed names of the same local DNS record can be set one after the
+// other with the mappings obtained before the first one was set
+func TestPiHoleDNSProviderSharedRecord(t *testing.T) {
+	f := &fakePiHole{hosts: []string{"10.0.0.2 home.lan media.lan nas.lan"}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultPiHoleDNSProviderConfig
+	c.URL = s.URL
+	c.Password = "secret"
+	c.ARecords = StringList{"home.lan", "media.lan"}
+	p := NewPiHoleDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.3", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantHosts := "10.0.0.3 home.lan,10.0.0.3 media.lan,10.0.0.2 nas.lan"
+	if got := strings.Join(f.hosts, ","); got != wantHosts {
+		t.Errorf("got %s, wanted %s", got, wantHosts)
+	}
+}
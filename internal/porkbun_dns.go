@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// PorkbunDNSProviderConfig Configuration for the Porkbun DNS Provider
+type PorkbunDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_PORKBUN_PROVIDER_ENABLE" required:"false"`
+
+	// Porkbun API key
+	APIKey string `yaml:"apiKey" envconfig:"DDNS_PORKBUN_PROVIDER_API_KEY" required:"false" secret:"true"`
+
+	// Porkbun secret API key
+	SecretAPIKey string `yaml:"secretAPIKey" envconfig:"DDNS_PORKBUN_PROVIDER_SECRET_API_KEY" required:"false" secret:"true"`
+
+	// Domain the A records belong to
+	Domain string `yaml:"domain" envconfig:"DDNS_PORKBUN_PROVIDER_DOMAIN" required:"false"`
+
+	// Url of the Porkbun API
+	BaseURL string `yaml:"baseURL" envconfig:"DDNS_PORKBUN_PROVIDER_BASE_URL" required:"false"`
+
+	// TTL of the A records in seconds
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_PORKBUN_PROVIDER_TTL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_PORKBUN_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultPorkbunDNSProviderConfig = &PorkbunDNSProviderConfig{
+	Enable:       false,
+	APIKey:       "",
+	SecretAPIKey: "",
+	Domain:       "",
+	BaseURL:      "https://api.porkbun.com/api/json/v3",
+	TTL:          600,
+	ARecords:     nil,
+}
+
+// porkbunRequest Body of every request, the credentials are sent alongside the parameters
+type porkbunRequest struct {
+	APIKey       string `json:"apikey"`
+	SecretAPIKey string `json:"secretapikey"`
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Content      string `json:"content,omitempty"`
+	TTL          string `json:"ttl,omitempty"`
+}
+
+type porkbunResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Records []porkbunRecord `json:"records"`
+}
+
+type porkbunRecord struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// PorkbunDNSProvider Porkbun DNS Provider
+type PorkbunDNSProvider struct {
+	apiKey       string
+	secretAPIKey string
+	domain       string
+	baseURL      string
+	ttl          int64
+	aRecords     []string
+	client       *http.Client
+}
+
+// NewPorkbunDNSProvider Returns an instance of PorkbunDNSProvider based on the passed configuration
+func NewPorkbunDNSProvider(config *PorkbunDNSProviderConfig) *PorkbunDNSProvider {
+	return &PorkbunDNSProvider{
+		apiKey:       config.APIKey,
+		secretAPIKey: config.SecretAPIKey,
+		domain:       strings.TrimSuffix(config.Domain, "."),
+		baseURL:      strings.TrimSuffix(config.BaseURL, "/"),
+		ttl:          config.TTL,
+		aRecords:     config.ARecords,
+		client:       &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the id and ip address are empty if a name has no A record yet
+func (p *PorkbunDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range p.aRecords {
+		var r porkbunResponse
+		if err := p.do(ctx, "/dns/retrieveByNameType/"+url.PathEscape(p.domain)+"/A/"+url.PathEscape(p.subdomain(name)), &porkbunRequest{}, &r); err != nil {
+			return nil, fmt.Errorf("could not retrieve A record %s: %w", name, err)
+		}
+
+		m := RecordAddressMapping{ARecord: name}
+		if len(r.Records) > 0 {
+			m.ID = r.Records[0].ID
+			m.IPAddress = r.Records[0].Content
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Set the A records of the name to the provided ip address, the record is created if it does not exist yet
+func (p *PorkbunDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	ttl := strconv.FormatInt(p.ttl, 10)
+	if m.ID == "" {
+		payload := &porkbunRequest{Name: p.subdomain(m.ARecord), Type: "A", Content: ipAddress, TTL: ttl}
+		if err := p.do(ctx, "/dns/create/"+url.PathEscape(p.domain), payload, &porkbunResponse{}); err != nil {
+			return fmt.Errorf("could not create A record %s: %w", m.ARecord, err)
+		}
+		return nil
+	}
+
+	payload := &porkbunRequest{Content: ipAddress, TTL: ttl}
+	if err := p.do(ctx, "/dns/editByNameType/"+url.PathEscape(p.domain)+"/A/"+url.PathEscape(p.subdomain(m.ARecord)), payload, &porkbunResponse{}); err != nil {
+		return fmt.Errorf("could not edit A record %s: %w", m.ARecord, err)
+	}
+	return nil
+}
+
+// subdomain Returns the name relative to the domain, empty for the apex
+func (p *PorkbunDNSProvider) subdomain(name string) string {
+	if s := relativeRecordName(name, p.domain); s != "@" {
+		return s
+	}
+	return ""
+}
+
+// do POST the payload with the credentials to the API and decode the response into v, responses without SUCCESS status are errors.
+// Payloads are not logged as they contain the credentials.
+func (p *PorkbunDNSProvider) do(ctx context.Context, path string, payload *porkbunRequest, v *porkbunResponse) error {
+	payload.APIKey = p.apiKey
+	payload.SecretAPIKey = p.secretAPIKey
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	log.Debug().Msgf("Executing POST request against %s", path)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+		}
+		return fmt.Errorf("could not decode the response from %s: %w", path, err)
+	}
+	if v.Status != "SUCCESS" {
+		return fmt.Errorf("response status from %s was %s: %s", path, v.Status, v.Message)
+	}
+
+	return nil
+}
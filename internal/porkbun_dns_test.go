@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePorkbun An in-process stand-in for the Porkbun DNS API of a single domain
+type fakePorkbun struct {
+	mu      sync.Mutex
+	records map[string]porkbunRecord
+	ttls    map[string]string
+}
+
+func (f *fakePorkbun) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var req porkbunRequest
+	if r.Method != "POST" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.APIKey != "pk1_key" || req.SecretAPIKey != "sk1_secret" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(porkbunResponse{Status: "ERROR", Message: "Invalid API key. (002)"})
+		return
+	}
+
+	// The apex has an empty subdomain, with or without a trailing slash
+	subdomain := func(prefix string) (string, bool) {
+		s, ok := strings.CutPrefix(r.URL.Path, prefix)
+		return strings.TrimSuffix(s, "/"), ok || r.URL.Path+"/" == prefix
+	}
+
+	if name, ok := subdomain("/dns/retrieveByNameType/example.com/A/"); ok {
+		res := porkbunResponse{Status: "SUCCESS", Records: []porkbunRecord{}}
+		if record, ok := f.records[name]; ok {
+			res.Records = append(res.Records, record)
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	} else if name, ok := subdomain("/dns/editByNameType/example.com/A/"); ok {
+		record := f.records[name]
+		record.Content = req.Content
+		f.records[name] = record
+		f.ttls[name] = req.TTL
+		_ = json.NewEncoder(w).Encode(porkbunResponse{Status: "SUCCESS"})
+	} else if r.URL.Path == "/dns/create/example.com" {
+		fqdn := strings.TrimPrefix(req.Name+".example.com", ".")
+		f.records[req.Name] = porkbunRecord{ID: fmt.Sprint(len(f.records) + 1), Name: fqdn, Type: req.Type, Content: req.Content}
+		f.ttls[req.Name] = req.TTL
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "SUCCESS", "id": len(f.records)})
+	} else {
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestPorkbunDNSProvider tests that records are retrieved, edited and created with the credentials in the request bodies
+func TestPorkbunDNSProvider(t *testing.T) {
+	f := &fakePorkbun{ttls: map[string]string{}, records: map[string]porkbunRecord{
+		"":    {ID: "1", Name: "example.com", Type: "A", Content: "10.0.0.1"},
+		"www": {ID: "2", Name: "www.example.com", Type: "A", Content: "10.0.0.2"},
+	}}
+	s := httptest.NewServer(f)
+	defer s.Close()
+
+	c := *defaultPorkbunDNSProviderConfig
+	c.APIKey = "pk1_key"
+	c.SecretAPIKey = "sk1_secret"
+	c.Domain = "example.com"
+	c.BaseURL = s.URL
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	p := NewPorkbunDNSProvider(&c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecordAddressMapping{
+		{ID: "1", ARecord: "example.com", IPAddress: "10.0.0.1"},
+		{ID: "2", ARecord: "www.example.com", IPAddress: "10.0.0.2"},
+		{ID: "", ARecord: "new.example.com", IPAddress: ""},
+	}
+	for i, m := range ms {
+		if m != want[i] {
+			t.Errorf("got %v, wanted %v", m, want[i])
+		}
+	}
+
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.3", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"", "www", "new"} {
+		if f.records[name].Content != "10.0.0.3" || f.ttls[name] != "600" {
+			t.Errorf("got %s with ttl %s for %q, wanted 10.0.0.3 with ttl 600", f.records[name].Content, f.ttls[name], name)
+		}
+	}
+
+	p.secretAPIKey = "wrong"
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil || !strings.Contains(err.Error(), "ERROR: Invalid API key. (002)") {
+		t.Errorf("got %v, wanted the error of the API", err)
+	}
+}
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// PowerDNSDNSProviderConfig Configuration for the PowerDNS Authoritative Server DNS Provider
+type PowerDNSDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_POWERDNS_PROVIDER_ENABLE" required:"false"`
+
+	// Url of the PowerDNS webserver, without the /api/v1 path
+	URL string `yaml:"url" envconfig:"DDNS_POWERDNS_PROVIDER_URL" required:"false"`
+
+	// Key sent in the X-API-Key header
+	APIKey string `yaml:"apiKey" envconfig:"DDNS_POWERDNS_PROVIDER_API_KEY" required:"false" secret:"true"`
+
+	// ID of the server, always localhost for the authoritative server
+	ServerID string `yaml:"serverID" envconfig:"DDNS_POWERDNS_PROVIDER_SERVER_ID" required:"false"`
+
+	// Zone the A records belong to
+	Zone string `yaml:"zone" envconfig:"DDNS_POWERDNS_PROVIDER_ZONE" required:"false"`
+
+	// TTL of created A records in seconds, existing record sets keep their TTL
+	TTL uint32 `yaml:"ttl" envconfig:"DDNS_POWERDNS_PROVIDER_TTL" required:"false"`
+
+	// Switch to rectify the zone after an update, required for DNSSEC signed zones without API-RECTIFY
+	Rectify bool `yaml:"rectify" envconfig:"DDNS_POWERDNS_PROVIDER_RECTIFY" required:"false"`
+
+	// Switch to send a DNS NOTIFY to the secondaries of the zone after an update
+	Notify bool `yaml:"notify" envconfig:"DDNS_POWERDNS_PROVIDER_NOTIFY" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_POWERDNS_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultPowerDNSDNSProviderConfig = &PowerDNSDNSProviderConfig{
+	Enable:   false,
+	URL:      "http://127.0.0.1:8081",
+	APIKey:   "",
+	ServerID: "localhost",
+	Zone:     "",
+	TTL:      300,
+	Rectify:  false,
+	Notify:   false,
+	ARecords: nil,
+}
+
+type powerDNSZone struct {
+	Name   string          `json:"name"`
+	RRsets []powerDNSRRset `json:"rrsets"`
+}
+
+type powerDNSRRset struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	TTL        uint32           `json:"ttl"`
+	ChangeType string           `json:"changetype,omitempty"`
+	Records    []powerDNSRecord `json:"records"`
+}
+
+type powerDNSRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+type powerDNSPatchZonePayload struct {
+	RRsets []powerDNSRRset `json:"rrsets"`
+}
+
+// PowerDNSDNSProvider DNS Provider using the HTTP API of the PowerDNS Authoritative Server
+type PowerDNSDNSProvider struct {
+	url      string
+	apiKey   string
+	serverID string
+	zone     string
+	ttl      uint32
+	rectify  bool
+	notify   bool
+	aRecords []string
+	client   *http.Client
+}
+
+// NewPowerDNSDNSProvider Returns an instance of PowerDNSDNSProvider based on the passed configuration
+func NewPowerDNSDNSProvider(config *PowerDNSDNSProviderConfig) *PowerDNSDNSProvider {
+	return &PowerDNSDNSProvider{
+		url:      strings.TrimSuffix(config.URL, "/"),
+		apiKey:   config.APIKey,
+		serverID: config.ServerID,
+		zone:     dns.Fqdn(config.Zone),
+		ttl:      config.TTL,
+		rectify:  config.Rectify,
+		notify:   config.Notify,
+		aRecords: config.ARecords,
+		client:   &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the first enabled record of an A record set is used and the ip address is empty if a name has no A record set yet
+func (p *PowerDNSDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	zone, err := p.getZone(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range p.aRecords {
+		m := RecordAddressMapping{ID: dns.Fqdn(name), ARecord: name}
+		if rrset := zone.aRRset(dns.Fqdn(name)); rrset != nil {
+			for _, r := range rrset.Records {
+				if !r.Disabled {
+					m.IPAddress = r.Content
+					break
+				}
+			}
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Replace the current ip address of the A record set with the provided one, other records of the set are kept
+func (p *PowerDNSDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	zone, err := p.getZone(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := dns.Fqdn(m.ARecord)
+	rrset := powerDNSRRset{Name: name, Type: "A", TTL: p.ttl, ChangeType: "REPLACE"}
+	replaced := false
+	if current := zone.aRRset(name); current != nil {
+		rrset.TTL = current.TTL
+		for _, r := range current.Records {
+			if r.Content == ipAddress {
+				continue
+			}
+			if !replaced && !r.Disabled && r.Content == m.IPAddress {
+				r.Content = ipAddress
+				replaced = true
+			}
+			rrset.Records = append(rrset.Records, r)
+		}
+	}
+	if !replaced {
+		rrset.Records = append([]powerDNSRecord{{Content: ipAddress}}, rrset.Records...)
+	}
+
+	if err := p.do(ctx, "PATCH", p.zonePath(), &powerDNSPatchZonePayload{RRsets: []powerDNSRRset{rrset}}, nil); err != nil {
+		return fmt.Errorf("could not replace A record set %s: %w", name, err)
+	}
+
+	if p.rectify {
+		if err := p.do(ctx, "PUT", p.zonePath()+"/rectify", nil, nil); err != nil {
+			return fmt.Errorf("could not rectify zone %s: %w", p.zone, err)
+		}
+	}
+	if p.notify {
+		if err := p.do(ctx, "PUT", p.zonePath()+"/notify", nil, nil); err != nil {
+			return fmt.Errorf("could not notify the secondaries of zone %s: %w", p.zone, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PowerDNSDNSProvider) getZone(ctx context.Context) (*powerDNSZone, error) {
+	var zone powerDNSZone
+	if err := p.do(ctx, "GET", p.zonePath(), nil, &zone); err != nil {
+		return nil, fmt.Errorf("could not get zone %s: %w", p.zone, err)
+	}
+	return &zone, nil
+}
+
+func (p *PowerDNSDNSProvider) zonePath() string {
+	return "/api/v1/servers/" + url.PathEscape(p.serverID) + "/zones/" + url.PathEscape(p.zone)
+}
+
+// aRRset Returns the A record set of the name or nil if it does not exist
+func (z *powerDNSZone) aRRset(name string) *powerDNSRRset {
+	for i, rrset := range z.RRsets {
+		if rrset.Type == "A" && strings.EqualFold(rrset.Name, name) {
+			return &z.RRsets[i]
+		}
+	}
+	return nil
+}
+
+// do Send a request with the json encoded payload to the API and decode the json response into v if not nil
+func (p *PowerDNSDNSProvider) do(ctx context.Context, method string, path string, payload any, v any) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Executing %s request against %s with payload %s", method, path, b)
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.url+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-API-Key", p.apiKey)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	// Zone changes are answered with 204
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Error != "" {
+			return fmt.Errorf("response status code from %s was %s: %s", path, res.Status, e.Error)
+		}
+		return fmt.Errorf("response status code from %s was %s, not 200", path, res.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
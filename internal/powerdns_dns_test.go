@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakePowerDNS An in-process stand-in for the zone endpoints of the PowerDNS Authoritative Server HTTP API
+type fakePowerDNS struct {
+	mu       sync.Mutex
+	zone     powerDNSZone
+	patches  []powerDNSPatchZonePayload
+	rectifys int
+	notifies int
+}
+
+func (f *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-API-Key") != "key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+		return
+	}
+
+	const zonePath = "/api/v1/servers/localhost/zones/example.com."
+	switch {
+	case r.Method == "GET" && r.URL.Path == zonePath:
+		_ = json.NewEncoder(w).Encode(f.zone)
+	case r.Method == "PATCH" && r.URL.Path == zonePath:
+		var payload powerDNSPatchZonePayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		f.patches = append(f.patches, payload)
+		for _, change := range payload.RRsets {
+			if change.ChangeType != "REPLACE" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			change.ChangeType = ""
+			if rrset := f.zone.aRRset(change.Name); rrset != nil {
+				*rrset = change
+			} else {
+				f.zone.RRsets = append(f.zone.RRsets, change)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "PUT" && r.URL.Path == zonePath+"/rectify":
+		f.rectifys++
+		_, _ = w.Write([]byte(`{"result":"Rectified"}`))
+	case r.Method == "PUT" && r.URL.Path == zonePath+"/notify":
+		f.notifies++
+		_, _ = w.Write([]byte(`{"result":"Notification queued"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "Not Found"})
+	}
+}
+
+func newTestPowerDNSDNSProvider(t *testing.T) (*fakePowerDNS, *PowerDNSDNSProvider) {
+	t.Helper()
+	f := &fakePowerDNS{zone: powerDNSZone{Name: "example.com.", RRsets: []powerDNSRRset{
+		{Name: "example.com.", Type: "A", TTL: 60, Records: []powerDNSRecord{{Content: "10.0.0.1"}}},
+		{Name: "www.example.com.", Type: "A", TTL: 3600, Records: []powerDNSRecord{
+			{Content: "10.0.0.9", Disabled: true},
+			{Content: "10.0.0.2"},
+			{Content: "10.0.0.3"},
+		}},
+	}}}
+	s := httptest.NewServer(f)
+	t.Cleanup(s.Close)
+
+	c := *defaultPowerDNSDNSProviderConfig
+	c.URL = s.URL
+	c.APIKey = "key"
+	c.Zone = "example.com"
+	c.Rectify = true
+	c.Notify = true
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	return f, NewPowerDNSDNSProvider(&c)
+}
+
+// TestPowerDNSDNSProvider tests that A record sets are replaced keeping their other records and TTL
+func TestPowerDNSDNSProvider(t *testing.T) {
+	f, p := newTestPowerDNSDNSProvider(t)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, m := range ms[1:] {
+		if err := p.SetARecordAddress(context.Background(), "10.0.0.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	www := f.zone.aRRset("www.example.com.")
+	wanted := []powerDNSRecord{{Content: "10.0.0.9", Disabled: true}, {Content: "10.0.0.1"}, {Content: "10.0.0.3"}}
+	if len(www.Records) != len(wanted) || www.TTL != 3600 {
+		t.Fatalf("got %v with ttl %d, wanted %v with ttl 3600", www.Records, www.TTL, wanted)
+	}
+	for i := range wanted {
+		if www.Records[i] != wanted[i] {
+			t.Errorf("got %v, wanted %v", www.Records[i], wanted[i])
+		}
+	}
+
+	created := f.zone.aRRset("new.example.com.")
+	if created == nil || len(created.Records) != 1 || created.Records[0].Content != "10.0.0.1" || created.TTL != 300 {
+		t.Errorf("got %v, wanted a new record set with 10.0.0.1 and ttl 300", created)
+	}
+
+	if f.rectifys != 2 || f.notifies != 2 {
+		t.Errorf("got %d rectifys and %d notifies, wanted 2 each", f.rectifys, f.notifies)
+	}
+}
+
+// TestPowerDNSDNSProviderError tests that error messages of the API are reported
+func TestPowerDNSDNSProviderError(t *testing.T) {
+	_, p := newTestPowerDNSDNSProvider(t)
+	p.zone = "example.org."
+
+	_, err := p.GetARecordAddresses(context.Background())
+	if err == nil || err.Error() != "could not get zone example.org.: response status code from /api/v1/servers/localhost/zones/example.org. was 404 Not Found: Not Found" {
+		t.Errorf("got %v, wanted the error of the API", err)
+	}
+}
@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// reloadDebounce Go duration without further file changes or signals to wait for before reloading
+const reloadDebounce = 500 * time.Millisecond
+
+// ConfigReloader Reloads the config file on SIGHUP or when the file changes and applies it to the Scheduler
+type ConfigReloader struct {
+	path      string
+	scheduler *Scheduler
+	current   *Config
+}
+
+// NewConfigReloader Returns an instance of ConfigReloader applying the config file at path to the Scheduler
+func NewConfigReloader(path string, s *Scheduler) *ConfigReloader {
+	return &ConfigReloader{
+		path:      path,
+		scheduler: s,
+		current:   GetConfig(),
+	}
+}
+
+// Watch Reload the config on SIGHUP and whenever the config file is written, replaced or created
+func (r *ConfigReloader) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Watch the directory, editors and config map mounts replace the file instead of writing to it
+	if r.path != "" {
+		if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	changes := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if r.affects(e) {
+					log.Debug().Msgf("Config file changed: %s", e)
+					changes <- struct{}{}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Msgf("Error while watching the config file: %s", err)
+			case <-signals:
+				log.Info().Msg("Received SIGHUP")
+				changes <- struct{}{}
+			}
+		}
+	}()
+
+	go func() {
+		for range debounce(changes, reloadDebounce) {
+			_ = r.Reload()
+		}
+	}()
+
+	if r.path == "" {
+		log.Info().Msg("Send SIGHUP to reload the config from the environment")
+	} else {
+		log.Info().Msgf("Watching %s for changes, send SIGHUP to reload", r.path)
+	}
+	return nil
+}
+
+// affects Returns true if the file system event may have changed the contents of the config file
+func (r *ConfigReloader) affects(e fsnotify.Event) bool {
+	if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) && !e.Has(fsnotify.Rename) {
+		return false
+	}
+
+	// Config map mounts swap a symlinked directory next to the config file
+	return filepath.Clean(e.Name) == filepath.Clean(r.path) || filepath.Base(e.Name) == "..data"
+}
+
+// Reload Gather, validate and apply the config, the current config is kept if any step fails
+func (r *ConfigReloader) Reload() error {
+	err := r.reload()
+	r.scheduler.setReloadError(err)
+	if err != nil {
+		log.Error().Msgf("Could not reload the config file at %s, keeping the current config: %s", r.path, err)
+		ConfigReloadsCounter.WithLabelValues("failure").Inc()
+		ConfigLastReloadSuccessGauge.WithLabelValues().Set(0)
+		return err
+	}
+
+	log.Info().Msgf("Reloaded the config file at %s", r.path)
+	ConfigReloadsCounter.WithLabelValues("success").Inc()
+	ConfigLastReloadSuccessGauge.WithLabelValues().Set(1)
+	return nil
+}
+
+func (r *ConfigReloader) reload() error {
+	c, err := LoadConfig(r.path)
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	// The dns server listener and the provider it answers from are created at startup only
+	if !reflect.DeepEqual(r.current.DNSServerConfig, c.DNSServerConfig) {
+		log.Warn().Msg("Changes to the dnsServer config section require a restart")
+		return errors.New("the dnsServer config section changed, a restart is required")
+	}
+
+	i := IPAddressProviderFactory(c)
+	if i == nil {
+		return errors.New("no IPAddressProvider was configured and enabled")
+	}
+	d := DNSProviderFactory(c)
+	if d == nil {
+		return errors.New("no DNSProvider was configured and enabled")
+	}
+
+	for _, section := range restartRequired(r.current, c) {
+		log.Warn().Msgf("Changes to the %s config section require a restart and were not applied", section)
+	}
+
+	// The dns server answers from the provider it was started with, which also holds the addresses in memory
+	if c.DNSServerConfig.Enable {
+		_, d = r.scheduler.Syncer().Providers()
+	}
+
+	r.scheduler.Reload(c, i, d)
+	global.Store(c)
+	r.current = c
+	return nil
+}
+
+// restartRequired Returns the config sections that differ between the configs but are only read at startup
+func restartRequired(current *Config, next *Config) []string {
+	sections := []struct {
+		name    string
+		current any
+		next    any
+	}{
+		{"metricsServer", current.MetricsServerConfig, next.MetricsServerConfig},
+		{"tracing", current.TracingConfig, next.TracingConfig},
+		{"mqtt", current.MQTTConfig, next.MQTTConfig},
+		{"health", current.HealthConfig, next.HealthConfig},
+		{"controlAPI", current.ControlAPIConfig, next.ControlAPIConfig},
+		{"netlinkEvents", current.NetlinkEventsConfig, next.NetlinkEventsConfig},
+	}
+
+	var changed []string
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.current, s.next) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
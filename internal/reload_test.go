@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const reloadTestConfig = `
+waitInterval: "%s"
+staticIPAddressProvider:
+  enable: true
+  address: "10.0.0.3"
+cloudflareDNSProvider:
+  enable: true
+  apiToken: "token"
+  zoneID: "zone"
+  aRecords:
+    - "example.com"
+`
+
+func writeReloadTestConfig(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestConfigReloader(t *testing.T) (*ConfigReloader, *Scheduler, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestConfig, "1m"))
+	if err := GatherConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := newTestScheduler(nil)
+	return NewConfigReloader(path, s), s, path
+}
+
+// TestConfigReloaderReload tests that a valid config is applied to the Scheduler and its Syncer
+func TestConfigReloaderReload(t *testing.T) {
+	r, s, path := newTestConfigReloader(t)
+	writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestConfig, "5m"))
+
+	before := testutil.ToFloat64(ConfigReloadsCounter.WithLabelValues("success"))
+	if err := r.Reload(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if got := GetConfig().WaitInterval; got != 5*time.Minute {
+		t.Errorf("got %s, wanted %s", got, 5*time.Minute)
+	}
+	if got := s.waitInterval; got != 5*time.Minute {
+		t.Errorf("got %s, wanted %s", got, 5*time.Minute)
+	}
+	if got := s.Syncer().ProviderHealth()["ipAddressProvider"].Name; got != "StaticIPAddressProvider" {
+		t.Errorf("got %s, wanted StaticIPAddressProvider", got)
+	}
+	if got := testutil.ToFloat64(ConfigReloadsCounter.WithLabelValues("success")) - before; got != 1 {
+		t.Errorf("got %g successful reloads, wanted 1", got)
+	}
+	if got := testutil.ToFloat64(ConfigLastReloadSuccessGauge.WithLabelValues()); got != 1 {
+		t.Errorf("got %g, wanted 1", got)
+	}
+}
+
+// TestConfigReloaderReloadInvalid tests that the current config is kept if the new one cannot be parsed or validated
+func TestConfigReloaderReloadInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"syntax":   "waitInterval: [",
+		"interval": fmt.Sprintf(reloadTestConfig, "0s"),
+		"provider": "waitInterval: \"5m\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, s, path := newTestConfigReloader(t)
+			current := GetConfig()
+			writeReloadTestConfig(t, path, content)
+
+			before := testutil.ToFloat64(ConfigReloadsCounter.WithLabelValues("failure"))
+			if err := r.Reload(); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if GetConfig() != current {
+				t.Error("expected the current config to be kept")
+			}
+			if got := s.waitInterval; got != time.Hour {
+				t.Errorf("got %s, wanted %s", got, time.Hour)
+			}
+			if got := testutil.ToFloat64(ConfigReloadsCounter.WithLabelValues("failure")) - before; got != 1 {
+				t.Errorf("got %g failed reloads, wanted 1", got)
+			}
+			if got := testutil.ToFloat64(ConfigLastReloadSuccessGauge.WithLabelValues()); got != 0 {
+				t.Errorf("got %g, wanted 0", got)
+			}
+
+			var rd Readiness
+			serveHealth(t, s, defaultHealthConfig, "/readyz", &rd)
+			if rd.ConfigReloadError == "" || s.Status().ConfigReloadError == "" {
+				t.Error("wanted the reload error to be reported")
+			}
+			if _, ok := rd.Checks["config"]; ok {
+				t.Errorf("got %+v, wanted a failed reload not to fail readiness", rd.Checks["config"])
+			}
+
+			writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestConfig, "1m"))
+			if err := r.Reload(); err != nil {
+				t.Fatal(err)
+			}
+			rd = Readiness{}
+			serveHealth(t, s, defaultHealthConfig, "/readyz", &rd)
+			if rd.ConfigReloadError != "" {
+				t.Errorf("got %s, wanted no reload error after a successful reload", rd.ConfigReloadError)
+			}
+		})
+	}
+}
+
+// TestConfigReloaderWatch tests that writing the config file reloads it
+func TestConfigReloaderWatch(t *testing.T) {
+	r, _, path := newTestConfigReloader(t)
+	if err := r.Watch(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestConfig, "7m"))
+	deadline := time.Now().Add(5 * time.Second)
+	for GetConfig().WaitInterval != 7*time.Minute {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %s, wanted %s", GetConfig().WaitInterval, 7*time.Minute)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRestartRequired tests that only changed sections read at startup are reported
+func TestRestartRequired(t *testing.T) {
+	current := defaultConfig
+	next := defaultConfig
+	next.WaitInterval = time.Hour
+	next.MQTTConfig.Enable = true
+
+	got := restartRequired(&current, &next)
+	if len(got) != 1 || got[0] != "mqtt" {
+		t.Errorf("got %v, wanted [mqtt]", got)
+	}
+}
+
+// TestConfigReloaderReloadSecrets tests that secrets referenced by the config are read again on reload
+func TestConfigReloaderReloadSecrets(t *testing.T) {
+	secret := writeSecretFile(t, "old-token")
+	t.Setenv("DDNS_CLOUDFLARE_API_TOKEN", "file:"+secret)
+	r, _, _ := newTestConfigReloader(t)
+	if got := GetConfig().CloudflareDNSProviderConfig.APIToken; got != "old-token" {
+		t.Fatalf("got %s, wanted old-token", got)
+	}
+
+	if err := os.WriteFile(secret, []byte("new-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if got := GetConfig().CloudflareDNSProviderConfig.APIToken; got != "new-token" {
+		t.Errorf("got %s, wanted new-token", got)
+	}
+}
+
+const reloadTestDNSServerConfig = `
+waitInterval: "%s"
+staticIPAddressProvider:
+  enable: true
+  address: "10.0.0.3"
+dnsServer:
+  enable: true
+  zone: "dyn.example.com"
+  nameservers:
+    - "ns1.dyn.example.com"
+  aRecords:
+%s`
+
+// TestConfigReloaderReloadDNSServer tests that the provider the dns server answers from is kept along with its addresses
+// and that changes to the dnsServer section are rejected
+func TestConfigReloaderReloadDNSServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestDNSServerConfig, "1m", "    - \"home.dyn.example.com\"\n"))
+	if err := GatherConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	c := GetConfig()
+	d := DNSProviderFactory(c)
+	s := NewScheduler(c, NewSyncer(c, IPAddressProviderFactory(c), d, false), nil)
+	r := NewConfigReloader(path, s)
+	if err := s.Syncer().Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestDNSServerConfig, "2m", "    - \"home.dyn.example.com\"\n"))
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, got := s.Syncer().Providers(); got != d {
+		t.Fatalf("got %p, wanted the provider the dns server was started with %p", got, d)
+	}
+
+	writeReloadTestConfig(t, path, fmt.Sprintf(reloadTestDNSServerConfig, "3m", "    - \"home.dyn.example.com\"\n    - \"new.dyn.example.com\"\n"))
+	if err := r.Reload(); err == nil {
+		t.Fatal("wanted an error for a changed dnsServer section")
+	}
+	if got := GetConfig(); got.WaitInterval != 2*time.Minute || len(got.DNSServerConfig.ARecords) != 1 {
+		t.Errorf("got %s and %v, wanted the current config to be kept", got.WaitInterval, got.DNSServerConfig.ARecords)
+	}
+	if _, got := s.Syncer().Providers(); got != d {
+		t.Fatalf("got %p, wanted the provider the dns server was started with %p", got, d)
+	}
+
+	if got := d.(*DNSServerDNSProvider).addresses(); len(got) != 1 || got["home.dyn.example.com."] != "10.0.0.3" {
+		t.Errorf("got %v, wanted the address of home.dyn.example.com to be kept", got)
+	}
+}
+
+// TestConfigReloaderReloadDNSServerToggle tests that switching between the dns server and another dns provider is
+// rejected in both directions
+func TestConfigReloaderReloadDNSServerToggle(t *testing.T) {
+	dnsServer := fmt.Sprintf(reloadTestDNSServerConfig, "1m", "    - \"home.dyn.example.com\"\n")
+	cloudflare := fmt.Sprintf(reloadTestConfig, "1m")
+	for name, contents := range map[string][2]string{
+		"enable":  {cloudflare, dnsServer},
+		"disable": {dnsServer, cloudflare},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			writeReloadTestConfig(t, path, contents[0])
+			if err := GatherConfig(path); err != nil {
+				t.Fatal(err)
+			}
+			c := GetConfig()
+			d := DNSProviderFactory(c)
+			s := NewScheduler(c, NewSyncer(c, IPAddressProviderFactory(c), d, false), nil)
+			r := NewConfigReloader(path, s)
+
+			writeReloadTestConfig(t, path, contents[1])
+			if err := r.Reload(); err == nil {
+				t.Fatal("wanted an error for a toggled dnsServer section")
+			}
+			if got := GetConfig(); got != c {
+				t.Errorf("got %p, wanted the current config %p to be kept", got, c)
+			}
+			if _, got := s.Syncer().Providers(); got != d {
+				t.Errorf("got %p, wanted the current provider %p to be kept", got, d)
+			}
+			if s.ReloadError() == nil {
+				t.Error("wanted the reload error to be recorded")
+			}
+		})
+	}
+}
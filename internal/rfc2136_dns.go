@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+// RFC2136DNSProviderConfig Configuration for the RFC 2136 dynamic update DNS Provider
+type RFC2136DNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_RFC2136_PROVIDER_ENABLE" required:"false"`
+
+	// Host and port of the primary name server accepting updates
+	Server string `yaml:"server" envconfig:"DDNS_RFC2136_PROVIDER_SERVER" required:"false"`
+
+	// Zone the A records belong to
+	Zone string `yaml:"zone" envconfig:"DDNS_RFC2136_PROVIDER_ZONE" required:"false"`
+
+	// Transport used for queries and updates, possible values: udp, tcp
+	Protocol string `yaml:"protocol" envconfig:"DDNS_RFC2136_PROVIDER_PROTOCOL" required:"false"`
+
+	// TTL of the A records in seconds
+	TTL uint32 `yaml:"ttl" envconfig:"DDNS_RFC2136_PROVIDER_TTL" required:"false"`
+
+	// Name of the TSIG key, messages are not signed if empty
+	TSIGKeyName string `yaml:"tsigKeyName" envconfig:"DDNS_RFC2136_PROVIDER_TSIG_KEY_NAME" required:"false"`
+
+	// Base64 encoded secret of the TSIG key
+	TSIGSecret string `yaml:"tsigSecret" envconfig:"DDNS_RFC2136_PROVIDER_TSIG_SECRET" required:"false" secret:"true"`
+
+	// Algorithm of the TSIG key, possible values: hmac-sha256, hmac-sha512
+	TSIGAlgorithm string `yaml:"tsigAlgorithm" envconfig:"DDNS_RFC2136_PROVIDER_TSIG_ALGORITHM" required:"false"`
+
+	// Go duration after which a query or update is aborted
+	Timeout time.Duration `yaml:"timeout" envconfig:"DDNS_RFC2136_PROVIDER_TIMEOUT" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_RFC2136_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultRFC2136DNSProviderConfig = &RFC2136DNSProviderConfig{
+	Enable:        false,
+	Server:        "127.0.0.1:53",
+	Zone:          "",
+	Protocol:      "udp",
+	TTL:           300,
+	TSIGKeyName:   "",
+	TSIGSecret:    "",
+	TSIGAlgorithm: "hmac-sha256",
+	Timeout:       10 * time.Second,
+	ARecords:      nil,
+}
+
+// rfc2136TSIGAlgorithms The supported TSIG algorithms by their config name
+var rfc2136TSIGAlgorithms = map[string]string{
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// RFC2136DNSProvider DNS Provider sending RFC 2136 UPDATE messages to a primary name server
+type RFC2136DNSProvider struct {
+	server        string
+	zone          string
+	ttl           uint32
+	tsigKeyName   string
+	tsigAlgorithm string
+	aRecords      []string
+	client        *dns.Client
+}
+
+// NewRFC2136DNSProvider Returns an instance of RFC2136DNSProvider based on the passed configuration
+func NewRFC2136DNSProvider(config *RFC2136DNSProviderConfig) *RFC2136DNSProvider {
+	p := &RFC2136DNSProvider{
+		server:        config.Server,
+		zone:          dns.Fqdn(config.Zone),
+		ttl:           config.TTL,
+		tsigAlgorithm: rfc2136TSIGAlgorithms[strings.ToLower(config.TSIGAlgorithm)],
+		client:        &dns.Client{Net: config.Protocol, Timeout: config.Timeout},
+	}
+
+	for _, r := range config.ARecords {
+		p.aRecords = append(p.aRecords, dns.Fqdn(r))
+	}
+
+	if config.TSIGKeyName != "" {
+		p.tsigKeyName = dns.Fqdn(config.TSIGKeyName)
+		p.client.TsigSecret = map[string]string{p.tsigKeyName: config.TSIGSecret}
+	}
+
+	return p
+}
+
+// GetARecordAddresses Query the primary name server for the current ip addresses of the names specified in the configuration,
+// the ip address is empty if a name has no A record yet
+func (p *RFC2136DNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range p.aRecords {
+		m := new(dns.Msg)
+		m.SetQuestion(name, dns.TypeA)
+		m.RecursionDesired = false
+
+		res, err := p.exchange(ctx, m)
+		if err != nil {
+			return nil, fmt.Errorf("could not query A record %s: %w", name, err)
+		}
+
+		mapping := RecordAddressMapping{ID: name, ARecord: strings.TrimSuffix(name, ".")}
+		for _, rr := range res.Answer {
+			if a, ok := rr.(*dns.A); ok {
+				mapping.IPAddress = a.A.String()
+				break
+			}
+		}
+		ms = append(ms, mapping)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Replace the A record set of the name with a single A record of the provided ip address
+func (p *RFC2136DNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	log.Info().Msgf("Setting A record %s to %s", m.ARecord, ipAddress)
+
+	ip := net.ParseIP(ipAddress).To4()
+	if ip == nil {
+		return fmt.Errorf("%s is not an ipv4 address", ipAddress)
+	}
+
+	name := dns.Fqdn(m.ARecord)
+	msg := new(dns.Msg)
+	msg.SetUpdate(p.zone)
+	msg.RemoveRRset([]dns.RR{&dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET}}})
+	msg.Insert([]dns.RR{&dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: p.ttl}, A: ip}})
+
+	if _, err := p.exchange(ctx, msg); err != nil {
+		return fmt.Errorf("could not update A record %s: %w", m.ARecord, err)
+	}
+
+	return nil
+}
+
+// exchange Sign the message if a TSIG key is configured, send it to the server and check the response code
+func (p *RFC2136DNSProvider) exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	if p.tsigKeyName != "" {
+		m.SetTsig(p.tsigKeyName, p.tsigAlgorithm, 300, time.Now().Unix())
+	}
+
+	res, _, err := p.client.ExchangeContext(ctx, m, p.server)
+	if err != nil {
+		return nil, err
+	}
+
+	// A name without any record does not exist yet
+	nxdomain := m.Opcode == dns.OpcodeQuery && res.Rcode == dns.RcodeNameError
+	if res.Rcode != dns.RcodeSuccess && !nxdomain {
+		return nil, fmt.Errorf("server %s responded with %s", p.server, dns.RcodeToString[res.Rcode])
+	}
+
+	return res, nil
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	rfc2136TestKeyName = "ddns-key."
+	rfc2136TestSecret  = "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1vbmx5"
+)
+
+// fakeAuthoritativeServer An in-process authoritative name server answering A queries and applying signed RFC 2136 updates
+type fakeAuthoritativeServer struct {
+	zone    string
+	mu      sync.Mutex
+	records map[string][]string
+	updates int
+}
+
+func (f *fakeAuthoritativeServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Opcode {
+	case dns.OpcodeQuery:
+		q := r.Question[0]
+		addresses, ok := f.records[q.Name]
+		if !ok {
+			m.Rcode = dns.RcodeNameError
+		}
+		for _, a := range addresses {
+			m.Answer = append(m.Answer, &dns.A{Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP(a)})
+		}
+	case dns.OpcodeUpdate:
+		if r.IsTsig() == nil || w.TsigStatus() != nil {
+			m.Rcode = dns.RcodeRefused
+			break
+		}
+		if r.Question[0].Name != f.zone {
+			m.Rcode = dns.RcodeNotZone
+			break
+		}
+		for _, rr := range r.Ns {
+			h := rr.Header()
+			if h.Class == dns.ClassANY && h.Rrtype == dns.TypeA {
+				delete(f.records, h.Name)
+			} else if a, ok := rr.(*dns.A); ok && h.Class == dns.ClassINET {
+				f.records[h.Name] = append(f.records[h.Name], a.A.String())
+			}
+		}
+		f.updates++
+	}
+
+	if r.IsTsig() != nil {
+		m.SetTsig(rfc2136TestKeyName, dns.HmacSHA256, 300, time.Now().Unix())
+	}
+	_ = w.WriteMsg(m)
+}
+
+func (f *fakeAuthoritativeServer) get(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.records[name]
+}
+
+// newFakeAuthoritativeServer Starts the stand-in on local udp and tcp ports and returns the address for the protocol
+func newFakeAuthoritativeServer(t *testing.T, protocol string) (*fakeAuthoritativeServer, string) {
+	t.Helper()
+	f := &fakeAuthoritativeServer{
+		zone:    "example.com.",
+		records: map[string][]string{"example.com.": {"10.0.0.1"}, "www.example.com.": {"10.0.0.2", "10.0.0.3"}},
+	}
+
+	started := make(chan struct{})
+	s := &dns.Server{
+		Handler:           f,
+		TsigSecret:        map[string]string{rfc2136TestKeyName: rfc2136TestSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// The default accepts no updates
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	if protocol == "tcp" {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Listener = l
+	} else {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.PacketConn = pc
+	}
+
+	go func() { _ = s.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = s.Shutdown() })
+
+	if s.Listener != nil {
+		return f, s.Listener.Addr().String()
+	}
+	return f, s.PacketConn.LocalAddr().String()
+}
+
+func newTestRFC2136DNSProvider(server string, protocol string) *RFC2136DNSProvider {
+	c := *defaultRFC2136DNSProviderConfig
+	c.Server = server
+	c.Protocol = protocol
+	c.Zone = "example.com"
+	c.TSIGKeyName = "ddns-key"
+	c.TSIGSecret = rfc2136TestSecret
+	c.Timeout = 2 * time.Second
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	return NewRFC2136DNSProvider(&c)
+}
+
+// TestRFC2136DNSProvider tests that records are queried and replaced by signed updates over udp and tcp
+func TestRFC2136DNSProvider(t *testing.T) {
+	for _, protocol := range []string{"udp", "tcp"} {
+		t.Run(protocol, func(t *testing.T) {
+			f, server := newFakeAuthoritativeServer(t, protocol)
+			p := newTestRFC2136DNSProvider(server, protocol)
+
+			got, err := p.GetARecordAddresses(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			want := []RecordAddressMapping{
+				{ID: "example.com.", ARecord: "example.com", IPAddress: "10.0.0.1"},
+				{ID: "www.example.com.", ARecord: "www.example.com", IPAddress: "10.0.0.2"},
+				{ID: "new.example.com.", ARecord: "new.example.com", IPAddress: ""},
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("got %+v, wanted %+v", got[i], want[i])
+				}
+			}
+
+			for _, m := range got[1:] {
+				if err := p.SetARecordAddress(context.Background(), "10.0.0.9", m); err != nil {
+					t.Fatalf("unexpected error %s", err)
+				}
+			}
+			for _, name := range []string{"www.example.com.", "new.example.com."} {
+				if got := f.get(name); len(got) != 1 || got[0] != "10.0.0.9" {
+					t.Errorf("got %v for %s, wanted [10.0.0.9]", got, name)
+				}
+			}
+			if got := f.get("example.com."); len(got) != 1 || got[0] != "10.0.0.1" {
+				t.Errorf("got %v, wanted example.com to be unchanged", got)
+			}
+		})
+	}
+}
+
+// TestRFC2136DNSProviderBadKey tests that updates signed with a wrong key are refused
+func TestRFC2136DNSProviderBadKey(t *testing.T) {
+	f, server := newFakeAuthoritativeServer(t, "tcp")
+	p := newTestRFC2136DNSProvider(server, "tcp")
+	p.client.TsigSecret[p.tsigKeyName] = "d3Jvbmcta2V5"
+
+	err := p.SetARecordAddress(context.Background(), "10.0.0.9", RecordAddressMapping{ARecord: "www.example.com"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if f.updates != 0 {
+		t.Errorf("got %d updates, wanted 0", f.updates)
+	}
+}
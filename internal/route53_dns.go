@@ -0,0 +1,389 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Route53DNSProviderConfig Configuration for the AWS Route 53 DNS Provider
+type Route53DNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_ROUTE53_PROVIDER_ENABLE" required:"false"`
+
+	// ID of the hosted zone the A records belong to
+	HostedZoneID string `yaml:"hostedZoneID" envconfig:"DDNS_ROUTE53_PROVIDER_HOSTED_ZONE_ID" required:"false"`
+
+	// Access key id, AWS_ACCESS_KEY_ID is used if empty
+	AccessKeyID string `yaml:"accessKeyID" envconfig:"DDNS_ROUTE53_PROVIDER_ACCESS_KEY_ID" required:"false"`
+
+	// Secret access key, AWS_SECRET_ACCESS_KEY is used if empty
+	SecretAccessKey string `yaml:"secretAccessKey" envconfig:"DDNS_ROUTE53_PROVIDER_SECRET_ACCESS_KEY" required:"false" secret:"true"`
+
+	// Session token of temporary credentials, AWS_SESSION_TOKEN is used if empty
+	SessionToken string `yaml:"sessionToken" envconfig:"DDNS_ROUTE53_PROVIDER_SESSION_TOKEN" required:"false" secret:"true"`
+
+	// Region requests are signed for
+	Region string `yaml:"region" envconfig:"DDNS_ROUTE53_PROVIDER_REGION" required:"false"`
+
+	// Url of the Route 53 API
+	Endpoint string `yaml:"endpoint" envconfig:"DDNS_ROUTE53_PROVIDER_ENDPOINT" required:"false"`
+
+	// TTL of the A records in seconds
+	TTL int64 `yaml:"ttl" envconfig:"DDNS_ROUTE53_PROVIDER_TTL" required:"false"`
+
+	// Switch to wait until a change was propagated to all Route 53 name servers
+	WaitForSync bool `yaml:"waitForSync" envconfig:"DDNS_ROUTE53_PROVIDER_WAIT_FOR_SYNC" required:"false"`
+
+	// Go duration after which waiting for a change to be propagated is aborted
+	SyncTimeout time.Duration `yaml:"syncTimeout" envconfig:"DDNS_ROUTE53_PROVIDER_SYNC_TIMEOUT" required:"false"`
+
+	// Go duration between two checks whether a change was propagated
+	SyncPollInterval time.Duration `yaml:"syncPollInterval" envconfig:"DDNS_ROUTE53_PROVIDER_SYNC_POLL_INTERVAL" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_ROUTE53_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultRoute53DNSProviderConfig = &Route53DNSProviderConfig{
+	Enable:           false,
+	HostedZoneID:     "",
+	AccessKeyID:      "",
+	SecretAccessKey:  "",
+	SessionToken:     "",
+	Region:           "us-east-1",
+	Endpoint:         "https://route53.amazonaws.com",
+	TTL:              300,
+	WaitForSync:      false,
+	SyncTimeout:      2 * time.Minute,
+	SyncPollInterval: 5 * time.Second,
+	ARecords:         nil,
+}
+
+type route53ResourceRecordSet struct {
+	Name            string                  `xml:"Name"`
+	Type            string                  `xml:"Type"`
+	TTL             int64                   `xml:"TTL"`
+	ResourceRecords []route53ResourceRecord `xml:"ResourceRecords>ResourceRecord"`
+}
+
+type route53ResourceRecord struct {
+	Value string `xml:"Value"`
+}
+
+type route53ListResourceRecordSetsResponse struct {
+	ResourceRecordSets []route53ResourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+type route53Change struct {
+	Action            string                   `xml:"Action"`
+	ResourceRecordSet route53ResourceRecordSet `xml:"ResourceRecordSet"`
+}
+
+type route53ChangeResourceRecordSetsRequest struct {
+	XMLName xml.Name        `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Comment string          `xml:"ChangeBatch>Comment"`
+	Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+type route53ChangeInfoResponse struct {
+	ChangeInfo struct {
+		ID     string `xml:"Id"`
+		Status string `xml:"Status"`
+	} `xml:"ChangeInfo"`
+}
+
+type route53ErrorResponse struct {
+	Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
+// route53Credentials Returns the configured credentials, falling back to the standard AWS environment variables
+func route53Credentials(config *Route53DNSProviderConfig) (string, string, string) {
+	if config.AccessKeyID != "" || config.SecretAccessKey != "" {
+		return config.AccessKeyID, config.SecretAccessKey, config.SessionToken
+	}
+	return os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")
+}
+
+// Route53DNSProvider AWS Route 53 DNS Provider
+type Route53DNSProvider struct {
+	hostedZoneID     string
+	accessKeyID      string
+	secretAccessKey  string
+	sessionToken     string
+	region           string
+	endpoint         string
+	ttl              int64
+	waitForSync      bool
+	syncTimeout      time.Duration
+	syncPollInterval time.Duration
+	aRecords         []string
+	client           *http.Client
+}
+
+// NewRoute53DNSProvider Returns an instance of Route53DNSProvider based on the passed configuration
+func NewRoute53DNSProvider(config *Route53DNSProviderConfig) *Route53DNSProvider {
+	accessKeyID, secretAccessKey, sessionToken := route53Credentials(config)
+
+	return &Route53DNSProvider{
+		hostedZoneID:     strings.TrimPrefix(config.HostedZoneID, "/hostedzone/"),
+		accessKeyID:      accessKeyID,
+		secretAccessKey:  secretAccessKey,
+		sessionToken:     sessionToken,
+		region:           config.Region,
+		endpoint:         strings.TrimSuffix(config.Endpoint, "/"),
+		ttl:              config.TTL,
+		waitForSync:      config.WaitForSync,
+		syncTimeout:      config.SyncTimeout,
+		syncPollInterval: config.SyncPollInterval,
+		aRecords:         config.ARecords,
+		client:           &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the current ip addresses for names specified in the configuration,
+// the ip address is empty if a name has no A record set yet
+func (p *Route53DNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	var ms []RecordAddressMapping
+
+	for _, name := range p.aRecords {
+		q := url.Values{}
+		q.Set("name", route53Fqdn(name))
+		q.Set("type", "A")
+		q.Set("maxitems", "1")
+
+		var r route53ListResourceRecordSetsResponse
+		if err := p.do(ctx, "GET", "/2013-04-01/hostedzone/"+p.hostedZoneID+"/rrset?"+q.Encode(), nil, &r); err != nil {
+			return nil, fmt.Errorf("could not list record sets of %s: %w", name, err)
+		}
+
+		// The listing starts at the name, the first record set belongs to the next name if it does not exist
+		m := RecordAddressMapping{ID: route53Fqdn(name), ARecord: name}
+		for _, s := range r.ResourceRecordSets {
+			if strings.EqualFold(s.Name, route53Fqdn(name)) && s.Type == "A" && len(s.ResourceRecords) > 0 {
+				m.IPAddress = s.ResourceRecords[0].Value
+			}
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress UPSERT the A record set of the provided name, see SetARecordAddresses
+func (p *Route53DNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	return p.SetARecordAddresses(ctx, ipAddress, []RecordAddressMapping{m})[0]
+}
+
+// SetARecordAddresses UPSERT the A record sets of all provided names in a single change batch and optionally wait until
+// the change is in sync. The batch is applied atomically, so the same error is returned for every A record.
+func (p *Route53DNSProvider) SetARecordAddresses(ctx context.Context, ipAddress string, ms []RecordAddressMapping) []error {
+	var names []string
+	req := route53ChangeResourceRecordSetsRequest{Comment: "Updated by ddns"}
+	for _, m := range ms {
+		names = append(names, m.ARecord)
+		req.Changes = append(req.Changes, route53Change{
+			Action: "UPSERT",
+			ResourceRecordSet: route53ResourceRecordSet{
+				Name:            route53Fqdn(m.ARecord),
+				Type:            "A",
+				TTL:             p.ttl,
+				ResourceRecords: []route53ResourceRecord{{Value: ipAddress}},
+			},
+		})
+	}
+	log.Info().Msgf("Setting A records %s to %s", strings.Join(names, ","), ipAddress)
+
+	err := p.changeRecordSets(ctx, req)
+	if err != nil {
+		err = fmt.Errorf("could not change record sets of %s: %w", strings.Join(names, ","), err)
+	}
+	errs := make([]error, len(ms))
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// changeRecordSets Submit the change batch and optionally wait until it is in sync
+func (p *Route53DNSProvider) changeRecordSets(ctx context.Context, req route53ChangeResourceRecordSetsRequest) error {
+	payload, err := xml.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	var r route53ChangeInfoResponse
+	if err := p.do(ctx, "POST", "/2013-04-01/hostedzone/"+p.hostedZoneID+"/rrset/", payload, &r); err != nil {
+		return err
+	}
+
+	if p.waitForSync {
+		return p.waitForChange(ctx, r.ChangeInfo.ID, r.ChangeInfo.Status)
+	}
+	return nil
+}
+
+// waitForChange Poll the change until its status is INSYNC
+func (p *Route53DNSProvider) waitForChange(ctx context.Context, id string, status string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.syncTimeout)
+	defer cancel()
+
+	id = strings.TrimPrefix(id, "/change/")
+	for status != "INSYNC" {
+		log.Debug().Msgf("Waiting for change %s with status %s", id, status)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("change %s was not in sync after %s", id, p.syncTimeout)
+		case <-time.After(p.syncPollInterval):
+		}
+
+		var r route53ChangeInfoResponse
+		if err := p.do(ctx, "GET", "/2013-04-01/change/"+id, nil, &r); err != nil {
+			return fmt.Errorf("could not get change %s: %w", id, err)
+		}
+		status = r.ChangeInfo.Status
+	}
+
+	log.Debug().Msgf("Change %s is in sync", id)
+	return nil
+}
+
+// do Send a signed request to the API and decode the XML response into v
+func (p *Route53DNSProvider) do(ctx context.Context, method string, path string, payload []byte, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, p.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "text/xml")
+	}
+	signAWSRequestV4(req, payload, p.accessKeyID, p.secretAccessKey, p.sessionToken, p.region, "route53", time.Now())
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Error().Msgf("error %s occurred while closing response body", err)
+		}
+	}(res.Body)
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var e route53ErrorResponse
+		if xml.Unmarshal(body, &e) == nil && e.Error.Code != "" {
+			return fmt.Errorf("response status code was %s: %s: %s", res.Status, e.Error.Code, e.Error.Message)
+		}
+		return fmt.Errorf("response status code was %s, not 200", res.Status)
+	}
+
+	return xml.Unmarshal(body, v)
+}
+
+// route53Fqdn Returns the name with a trailing dot as returned by the API
+func route53Fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// signAWSRequestV4 Add the AWS Signature Version 4 authorization headers for the credentials to the request
+func signAWSRequestV4(req *http.Request, payload []byte, accessKeyID, secretAccessKey, sessionToken, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(req.Header.Get(k))
+		}
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, s := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+// awsCanonicalQuery Returns the query sorted by key and value with RFC 3986 percent encoding
+func awsCanonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
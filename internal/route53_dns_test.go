@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRoute53 An in-process stand-in for the Route 53 record set and change APIs of a single hosted zone
+type fakeRoute53 struct {
+	mu          sync.Mutex
+	records     map[string]string
+	batches     [][]route53Change
+	changePolls int
+	auth        []string
+}
+
+func (f *fakeRoute53) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/") {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>invalid</Message></Error></ErrorResponse>`)
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/2013-04-01/hostedzone/Z123/rrset":
+		// Like Route 53 the listing starts at the name and may continue with the next one
+		name := r.URL.Query().Get("name")
+		next := name
+		if _, ok := f.records[name]; !ok {
+			next = "zzz.example.com."
+		}
+		_, _ = fmt.Fprintf(w, `<ListResourceRecordSetsResponse><ResourceRecordSets><ResourceRecordSet><Name>%s</Name><Type>A</Type><TTL>300</TTL>`+
+			`<ResourceRecords><ResourceRecord><Value>%s</Value></ResourceRecord></ResourceRecords></ResourceRecordSet></ResourceRecordSets></ListResourceRecordSetsResponse>`,
+			next, f.records[next])
+	case r.Method == "POST" && r.URL.Path == "/2013-04-01/hostedzone/Z123/rrset/":
+		b, _ := io.ReadAll(r.Body)
+		var req route53ChangeResourceRecordSetsRequest
+		if err := xml.Unmarshal(b, &req); err != nil || req.XMLName.Space != "https://route53.amazonaws.com/doc/2013-04-01/" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, c := range req.Changes {
+			f.records[c.ResourceRecordSet.Name] = c.ResourceRecordSet.ResourceRecords[0].Value
+		}
+		f.batches = append(f.batches, req.Changes)
+		_, _ = fmt.Fprint(w, `<ChangeResourceRecordSetsResponse><ChangeInfo><Id>/change/C1</Id><Status>PENDING</Status></ChangeInfo></ChangeResourceRecordSetsResponse>`)
+	case r.Method == "GET" && r.URL.Path == "/2013-04-01/change/C1":
+		f.changePolls++
+		status := "PENDING"
+		if f.changePolls > 1 {
+			status = "INSYNC"
+		}
+		_, _ = fmt.Fprintf(w, `<GetChangeResponse><ChangeInfo><Id>/change/C1</Id><Status>%s</Status></ChangeInfo></GetChangeResponse>`, status)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestRoute53DNSProvider(t *testing.T) (*fakeRoute53, *Route53DNSProviderConfig) {
+	t.Helper()
+	f := &fakeRoute53{records: map[string]string{"example.com.": "10.0.0.1", "www.example.com.": "10.0.0.2", "zzz.example.com.": "10.0.0.9"}}
+	s := httptest.NewServer(f)
+	t.Cleanup(s.Close)
+
+	c := *defaultRoute53DNSProviderConfig
+	c.Enable = true
+	c.HostedZoneID = "/hostedzone/Z123"
+	c.AccessKeyID = "AKIDTEST"
+	c.SecretAccessKey = "secret"
+	c.Endpoint = s.URL
+	c.SyncPollInterval = 10 * time.Millisecond
+	c.ARecords = StringList{"example.com", "www.example.com", "new.example.com"}
+	return f, &c
+}
+
+// TestRoute53DNSProvider tests that record sets are listed by name and the provided ones are UPSERTed in one batch
+func TestRoute53DNSProvider(t *testing.T) {
+	f, c := newTestRoute53DNSProvider(t)
+	c.WaitForSync = true
+	p := NewRoute53DNSProvider(c)
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "www.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	var updates []RecordAddressMapping
+	for _, m := range ms {
+		if m.IPAddress != "10.0.0.1" {
+			updates = append(updates, m)
+		}
+	}
+	for _, err := range p.SetARecordAddresses(context.Background(), "10.0.0.1", updates) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(f.batches) != 1 {
+		t.Fatalf("got %d batches, wanted 1", len(f.batches))
+	}
+	var names []string
+	for _, c := range f.batches[0] {
+		if c.Action != "UPSERT" || c.ResourceRecordSet.TTL != 300 {
+			t.Errorf("got %s with ttl %d, wanted UPSERT with ttl 300", c.Action, c.ResourceRecordSet.TTL)
+		}
+		names = append(names, c.ResourceRecordSet.Name)
+	}
+	if got, wanted := strings.Join(names, ","), "www.example.com.,new.example.com."; got != wanted {
+		t.Errorf("got %s, wanted %s", got, wanted)
+	}
+	if f.records["new.example.com."] != "10.0.0.1" {
+		t.Errorf("got %s, wanted %s", f.records["new.example.com."], "10.0.0.1")
+	}
+	if f.changePolls != 2 {
+		t.Errorf("got %d change polls, wanted 2", f.changePolls)
+	}
+}
+
+// TestRoute53DNSProviderCredentials tests that the AWS environment variables are used if no keys are configured
+func TestRoute53DNSProviderCredentials(t *testing.T) {
+	f, c := newTestRoute53DNSProvider(t)
+	c.AccessKeyID = ""
+	c.SecretAccessKey = ""
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+
+	if _, err := NewRoute53DNSProvider(c).GetARecordAddresses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(f.auth[0], "x-amz-security-token") {
+		t.Errorf("got %s, wanted the session token to be signed", f.auth[0])
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDOTHER")
+	_, err := NewRoute53DNSProvider(c).GetARecordAddresses(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "InvalidClientTokenId") {
+		t.Errorf("got %v, wanted InvalidClientTokenId", err)
+	}
+}
+
+// TestSignAWSRequestV4 tests the signature against the get-vanilla case of the AWS Signature Version 4 test suite
+func TestSignAWSRequestV4(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signAWSRequestV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "", "us-east-1", "service", now)
+
+	wanted := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != wanted {
+		t.Errorf("got %s, wanted %s", got, wanted)
+	}
+}
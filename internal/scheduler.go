@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SchedulerStatus The current state of the Scheduler
+type SchedulerStatus struct {
+	Paused            bool        `json:"paused"`
+	NextRun           time.Time   `json:"nextRun"`
+	LastReport        *SyncReport `json:"lastReport"`
+	ConfigReloadError string      `json:"configReloadError,omitempty"`
+}
+
+// Scheduler Repeatedly runs the Syncer, waiting between successful and failed attempts
+type Scheduler struct {
+	syncer  *Syncer
+	events  <-chan struct{}
+	trigger chan struct{}
+
+	mu            sync.Mutex
+	waitInterval  time.Duration
+	retryInterval time.Duration
+	subscribers   []func(SchedulerStatus)
+	paused        bool
+	nextRun       time.Time
+	busySince     time.Time
+	lastIteration time.Time
+	reloadError   error
+}
+
+// NewScheduler Returns an instance of Scheduler based on the passed configuration, a receive on events starts a synchronization immediately
+func NewScheduler(c *Config, s *Syncer, events <-chan struct{}) *Scheduler {
+	return &Scheduler{
+		syncer:        s,
+		waitInterval:  c.WaitInterval,
+		retryInterval: c.RetryInterval,
+		events:        events,
+		trigger:       make(chan struct{}, 1),
+	}
+}
+
+// Run Synchronize forever, never returns
+func (s *Scheduler) Run() {
+	for {
+		s.mu.Lock()
+		s.busySince = time.Now()
+		s.mu.Unlock()
+
+		s.mu.Lock()
+		paused, waitInterval, retryInterval := s.paused, s.waitInterval, s.retryInterval
+		s.mu.Unlock()
+
+		interval := waitInterval
+		if paused {
+			log.Info().Msgf("Synchronization is paused. Next attempt in %s", waitInterval)
+		} else if err := s.syncer.Sync(); err == nil {
+			log.Info().Msgf("Success. Next attempt in %s", waitInterval)
+		} else {
+			log.Error().Msgf("An error occurred: %s. Retrying in %s", err, retryInterval)
+			interval = retryInterval
+		}
+
+		s.mu.Lock()
+		s.lastIteration = time.Now()
+		s.busySince = time.Time{}
+		s.nextRun = s.lastIteration.Add(interval)
+		subscribers := s.subscribers
+		s.mu.Unlock()
+
+		status := s.Status()
+		for _, f := range subscribers {
+			f(status)
+		}
+
+		s.wait(interval)
+	}
+}
+
+// wait Blocks until the interval elapsed, an address change event was received or a synchronization was triggered
+func (s *Scheduler) wait(interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return
+		case _, ok := <-s.events:
+			if !ok {
+				// Fall back to the wait interval once the event source is gone
+				s.events = nil
+				log.Warn().Msg("Address change events stopped, synchronizing on the wait interval only")
+				continue
+			}
+			log.Info().Msg("Address change detected, synchronizing immediately")
+			return
+		case <-s.trigger:
+			log.Info().Msg("Synchronization was triggered")
+			return
+		}
+	}
+}
+
+// Reload Apply the intervals of the configuration and the providers to the next synchronizations and start one immediately
+func (s *Scheduler) Reload(c *Config, i IPAddressProvider, d DNSProvider) {
+	s.syncer.Reconfigure(c, i, d)
+
+	s.mu.Lock()
+	s.waitInterval = c.WaitInterval
+	s.retryInterval = c.RetryInterval
+	s.mu.Unlock()
+
+	s.Trigger()
+}
+
+// Subscribe Register a function called with the status after every iteration
+func (s *Scheduler) Subscribe(f func(SchedulerStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers = append(s.subscribers, f)
+}
+
+// Trigger Start a synchronization immediately, returns false if synchronization is paused
+func (s *Scheduler) Trigger() bool {
+	if s.Paused() {
+		return false
+	}
+
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+		// A trigger is already pending
+	}
+	return true
+}
+
+// Pause Skip all synchronizations until Resume is called
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	s.paused = true
+	s.mu.Unlock()
+	log.Info().Msg("Synchronization paused")
+}
+
+// Resume Continue synchronizing after Pause and start a synchronization immediately
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+	log.Info().Msg("Synchronization resumed")
+	s.Trigger()
+}
+
+// Paused Returns true if synchronization is paused
+func (s *Scheduler) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused
+}
+
+// Status Returns the current state of the Scheduler
+func (s *Scheduler) Status() SchedulerStatus {
+	report := s.syncer.LastReport()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := SchedulerStatus{
+		Paused:     s.paused,
+		NextRun:    s.nextRun,
+		LastReport: report,
+	}
+	if s.reloadError != nil {
+		status.ConfigReloadError = s.reloadError.Error()
+	}
+	return status
+}
+
+// iterations Returns since when the current iteration is running, when the last one finished and when the next is scheduled
+func (s *Scheduler) iterations() (time.Time, time.Time, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.busySince, s.lastIteration, s.nextRun
+}
+
+// ReloadError Returns the error of the last config reload, or nil if it succeeded or there was none yet
+func (s *Scheduler) ReloadError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reloadError
+}
+
+// setReloadError Remember the outcome of the last config reload
+func (s *Scheduler) setReloadError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reloadError = err
+}
+
+// Syncer Returns the Syncer run by the Scheduler
+func (s *Scheduler) Syncer() *Syncer {
+	return s.syncer
+}
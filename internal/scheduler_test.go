@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type countingIPAddressProvider struct {
+	attempts chan struct{}
+}
+
+func (c *countingIPAddressProvider) GetIPAddress(_ context.Context) (*string, error) {
+	c.attempts <- struct{}{}
+	a := "10.0.0.1"
+	return &a, nil
+}
+
+func newTestScheduler(events <-chan struct{}) (*Scheduler, chan struct{}) {
+	c := defaultConfig
+	c.WaitInterval = time.Hour
+	c.RetryInterval = time.Hour
+	attempts := make(chan struct{}, 10)
+	s := NewSyncer(&c, &countingIPAddressProvider{attempts: attempts}, newFakeDNSProvider(), false)
+	return NewScheduler(&c, s, events), attempts
+}
+
+func expectAttempt(t *testing.T, attempts chan struct{}) {
+	t.Helper()
+	select {
+	case <-attempts:
+	case <-time.After(time.Second):
+		t.Fatal("expected a synchronization attempt")
+	}
+}
+
+// TestSchedulerEvents tests that an event starts the next attempt before the wait interval elapsed
+func TestSchedulerEvents(t *testing.T) {
+	events := make(chan struct{})
+	s, attempts := newTestScheduler(events)
+	go s.Run()
+
+	expectAttempt(t, attempts)
+	events <- struct{}{}
+	expectAttempt(t, attempts)
+}
+
+// TestSchedulerTrigger tests that a trigger starts the next attempt and is refused while paused
+func TestSchedulerTrigger(t *testing.T) {
+	s, attempts := newTestScheduler(nil)
+	go s.Run()
+
+	expectAttempt(t, attempts)
+	if !s.Trigger() {
+		t.Fatal("expected trigger to be accepted")
+	}
+	expectAttempt(t, attempts)
+
+	s.Pause()
+	if s.Trigger() {
+		t.Error("expected trigger to be refused while paused")
+	}
+	if !s.Status().Paused {
+		t.Error("expected status to be paused")
+	}
+
+	s.Resume()
+	expectAttempt(t, attempts)
+	if s.Status().LastReport == nil {
+		t.Error("expected status to contain the last report")
+	}
+}
+
+// TestSchedulerEventsClosed tests that a closed event source falls back to the wait interval instead of synchronizing
+// continuously
+func TestSchedulerEventsClosed(t *testing.T) {
+	events := make(chan struct{})
+	s, attempts := newTestScheduler(events)
+	go s.Run()
+
+	expectAttempt(t, attempts)
+	close(events)
+
+	select {
+	case <-attempts:
+		t.Fatal("expected no synchronization attempt after the event source closed")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if !s.Trigger() {
+		t.Fatal("expected trigger to be accepted")
+	}
+	expectAttempt(t, attempts)
+}
@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// secretFilePrefix References a secret read from a file, e.g. file:/run/secrets/cloudflare
+	secretFilePrefix = "file:"
+
+	// secretEnvPrefix References a secret read from an environment variable, e.g. env:CLOUDFLARE_API_TOKEN
+	secretEnvPrefix = "env:"
+
+	// secretVaultPrefix References a secret read from HashiCorp Vault KV, e.g. vault:secret/data/ddns#cloudflare
+	secretVaultPrefix = "vault:"
+
+	// secretFileEnvSuffix Suffix of the environment variables naming a file a secret is read from
+	secretFileEnvSuffix = "_FILE"
+)
+
+// VaultConfig Config section governing secret references resolved from HashiCorp Vault
+type VaultConfig struct {
+	// Url of the Vault server, e.g. https://vault.example.com:8200
+	Address string `yaml:"address" envconfig:"DDNS_VAULT_ADDRESS" required:"false"`
+
+	// Token used to authenticate against Vault, may itself be a file: or env: reference
+	Token string `yaml:"token" envconfig:"DDNS_VAULT_TOKEN" required:"false" secret:"true"`
+
+	// Vault Enterprise namespace, only set if required
+	Namespace string `yaml:"namespace" envconfig:"DDNS_VAULT_NAMESPACE" required:"false"`
+
+	// Go duration after which a secret lookup is aborted
+	Timeout time.Duration `yaml:"timeout" envconfig:"DDNS_VAULT_TIMEOUT" required:"false"`
+}
+
+var defaultVaultConfig = &VaultConfig{
+	Address:   "",
+	Token:     "",
+	Namespace: "",
+	Timeout:   10 * time.Second,
+}
+
+// secretResolver Replaces secret references of a config with the referenced values
+type secretResolver struct {
+	vault  *VaultConfig
+	client *http.Client
+	cache  map[string]map[string]any
+	errs   ConfigErrors
+}
+
+// resolveSecrets Replaces the values of all fields tagged as secret that are set via a *_FILE environment variable or reference
+// a file, an environment variable or a Vault KV secret. Errors never contain the secret values.
+func resolveSecrets(c *Config) error {
+	r := &secretResolver{
+		vault:  &c.VaultConfig,
+		client: &http.Client{Timeout: c.VaultConfig.Timeout},
+		cache:  map[string]map[string]any{},
+	}
+
+	// The vault token is required to resolve all other vault references
+	r.resolveField("vault.token", "DDNS_VAULT_TOKEN", &c.VaultConfig.Token, false)
+	r.resolveStruct(reflect.ValueOf(c).Elem(), "")
+
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return r.errs
+}
+
+// resolveStruct Resolve all secret fields of the struct and its nested structs, path is the yaml path of the struct.
+// Sections with a disabled enable switch are skipped, so a shared config may reference secrets that only exist where
+// the section is enabled.
+func (r *secretResolver) resolveStruct(v reflect.Value, path string) {
+	if enable := v.FieldByName("Enable"); enable.Kind() == reflect.Bool && !enable.Bool() {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if path != "" {
+			name = path + "." + name
+		}
+
+		switch {
+		case f.Tag.Get("secret") == "true" && f.Type.Kind() == reflect.String:
+			if name == "vault.token" {
+				continue
+			}
+			s := v.Field(i).String()
+			r.resolveField(name, f.Tag.Get("envconfig"), &s, true)
+			v.Field(i).SetString(s)
+		case f.Type.Kind() == reflect.Struct:
+			r.resolveStruct(v.Field(i), name)
+		}
+	}
+}
+
+// resolveField Resolve a single secret, the *_FILE variable of the envconfig key takes effect if the value is not set otherwise
+func (r *secretResolver) resolveField(path string, envKey string, value *string, allowVault bool) {
+	if envKey != "" {
+		if file, ok := os.LookupEnv(envKey + secretFileEnvSuffix); ok {
+			if _, set := os.LookupEnv(envKey); set {
+				r.add(path, "only one of %s and %s%s may be set", envKey, envKey, secretFileEnvSuffix)
+				return
+			}
+			*value = secretFilePrefix + file
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(*value, secretFilePrefix):
+		file := strings.TrimPrefix(*value, secretFilePrefix)
+		b, err := os.ReadFile(file)
+		if err != nil {
+			r.add(path, "could not read secret file: %s", err)
+			return
+		}
+		*value = strings.TrimRight(string(b), "\r\n")
+	case strings.HasPrefix(*value, secretEnvPrefix):
+		key := strings.TrimPrefix(*value, secretEnvPrefix)
+		s, ok := os.LookupEnv(key)
+		if !ok {
+			r.add(path, "referenced environment variable %s is not set", key)
+			return
+		}
+		*value = s
+	case strings.HasPrefix(*value, secretVaultPrefix) && allowVault:
+		s, err := r.lookupVault(strings.TrimPrefix(*value, secretVaultPrefix))
+		if err != nil {
+			r.add(path, "could not read secret from vault: %s", err)
+			return
+		}
+		*value = s
+	}
+}
+
+func (r *secretResolver) add(path string, format string, args ...any) {
+	r.errs = append(r.errs, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// lookupVault Returns the key of the KV secret referenced as <path>#<key>, KV version 1 and 2 mounts are supported
+func (r *secretResolver) lookupVault(ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("reference %q must have the form <path>#<key>", ref)
+	}
+	if r.vault.Address == "" {
+		return "", fmt.Errorf("vault.address is required to resolve vault references")
+	}
+
+	data, ok := r.cache[path]
+	if !ok {
+		var err error
+		if data, err = r.readVault(path); err != nil {
+			return "", err
+		}
+		r.cache[path] = data
+	}
+
+	v, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", path, key)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s of secret %s is not a string", key, path)
+	}
+	return s, nil
+}
+
+// readVault Read the KV secret at the path, the data of KV version 2 secrets is unwrapped
+func (r *secretResolver) readVault(path string) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.vault.Timeout)
+	defer cancel()
+
+	url := strings.TrimSuffix(r.vault.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", r.vault.Token)
+	if r.vault.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.vault.Namespace)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status code for secret %s was %s, not 200", path, res.Status)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(b, &secret); err != nil {
+		return nil, fmt.Errorf("could not decode secret %s", path)
+	}
+
+	// KV version 2 nests the data next to its metadata
+	if nested, ok := secret.Data["data"].(map[string]any); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			return nested, nil
+		}
+	}
+	return secret.Data, nil
+}
@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSecretFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newSecretsTestConfig Returns the default config with all sections containing secrets used by the tests enabled
+func newSecretsTestConfig() Config {
+	c := defaultConfig
+	c.CloudflareDNSProviderConfig.Enable = true
+	c.URLIPAddressProviderConfig.Enable = true
+	c.ControlAPIConfig.Enable = true
+	return c
+}
+
+// newFakeVault starts a server answering KV version 1 and 2 reads for the token
+func newFakeVault(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/secret/data/ddns":
+			_, _ = w.Write([]byte(`{"data": {"data": {"cloudflare": "kv2-token"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/ddns":
+			_, _ = w.Write([]byte(`{"data": {"password": "kv1-password"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// TestResolveSecretsFileEnv tests that *_FILE environment variables are read and trailing newlines removed
+func TestResolveSecretsFileEnv(t *testing.T) {
+	t.Setenv("DDNS_CLOUDFLARE_API_TOKEN_FILE", writeSecretFile(t, "file-token\n"))
+
+	c := newSecretsTestConfig()
+	if err := resolveSecrets(&c); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if got := c.CloudflareDNSProviderConfig.APIToken; got != "file-token" {
+		t.Errorf("got %s, wanted file-token", got)
+	}
+}
+
+// TestResolveSecretsFileEnvConflict tests that setting a secret and its *_FILE variable is an error
+func TestResolveSecretsFileEnvConflict(t *testing.T) {
+	t.Setenv("DDNS_CLOUDFLARE_API_TOKEN", "token")
+	t.Setenv("DDNS_CLOUDFLARE_API_TOKEN_FILE", writeSecretFile(t, "file-token"))
+
+	c := newSecretsTestConfig()
+	var errs ConfigErrors
+	if err := resolveSecrets(&c); !errors.As(err, &errs) || errs[0].Path != "cloudflareDNSProvider.apiToken" {
+		t.Errorf("got %v, wanted an error for cloudflareDNSProvider.apiToken", err)
+	}
+}
+
+// TestResolveSecretsReferences tests that file: and env: references are resolved and only in secret fields
+func TestResolveSecretsReferences(t *testing.T) {
+	t.Setenv("TEST_DDNS_PASSWORD", "env-password")
+
+	c := newSecretsTestConfig()
+	c.CloudflareDNSProviderConfig.APIToken = "file:" + writeSecretFile(t, "file-token")
+	c.URLIPAddressProviderConfig.Password = "env:TEST_DDNS_PASSWORD"
+	c.URLIPAddressProviderConfig.Username = "env:TEST_DDNS_PASSWORD"
+	if err := resolveSecrets(&c); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if got := c.CloudflareDNSProviderConfig.APIToken; got != "file-token" {
+		t.Errorf("got %s, wanted file-token", got)
+	}
+	if got := c.URLIPAddressProviderConfig.Password; got != "env-password" {
+		t.Errorf("got %s, wanted env-password", got)
+	}
+	if got := c.URLIPAddressProviderConfig.Username; got != "env:TEST_DDNS_PASSWORD" {
+		t.Errorf("got %s, wanted the non secret field to be unchanged", got)
+	}
+}
+
+// TestResolveSecretsDisabled tests that references of disabled sections are not resolved
+func TestResolveSecretsDisabled(t *testing.T) {
+	c := defaultConfig
+	c.CloudflareDNSProviderConfig.APIToken = "file:" + filepath.Join(t.TempDir(), "missing")
+	if err := resolveSecrets(&c); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+// TestResolveSecretsMissing tests that unresolvable references are reported with their path
+func TestResolveSecretsMissing(t *testing.T) {
+	c := newSecretsTestConfig()
+	c.CloudflareDNSProviderConfig.APIToken = "file:" + filepath.Join(t.TempDir(), "missing")
+	c.ControlAPIConfig.Token = "env:TEST_DDNS_MISSING"
+
+	var errs ConfigErrors
+	if err := resolveSecrets(&c); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("got %v, wanted 2 errors", err)
+	}
+	if errs[0].Path != "controlAPI.token" || errs[1].Path != "cloudflareDNSProvider.apiToken" {
+		t.Errorf("got %s, wanted errors for controlAPI.token and cloudflareDNSProvider.apiToken", errs)
+	}
+}
+
+// TestResolveSecretsVault tests that vault references are read from KV version 1 and 2 mounts using a token from a file
+func TestResolveSecretsVault(t *testing.T) {
+	vault := newFakeVault(t, "vault-token")
+
+	c := newSecretsTestConfig()
+	c.VaultConfig.Address = vault.URL
+	c.VaultConfig.Token = "file:" + writeSecretFile(t, "vault-token\n")
+	c.CloudflareDNSProviderConfig.APIToken = "vault:secret/data/ddns#cloudflare"
+	c.URLIPAddressProviderConfig.Password = "vault:kv/ddns#password"
+	if err := resolveSecrets(&c); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if got := c.CloudflareDNSProviderConfig.APIToken; got != "kv2-token" {
+		t.Errorf("got %s, wanted kv2-token", got)
+	}
+	if got := c.URLIPAddressProviderConfig.Password; got != "kv1-password" {
+		t.Errorf("got %s, wanted kv1-password", got)
+	}
+}
+
+// TestResolveSecretsVaultErrors tests that failed vault lookups are reported without the token
+func TestResolveSecretsVaultErrors(t *testing.T) {
+	vault := newFakeVault(t, "vault-token")
+
+	for ref, message := range map[string]string{
+		"vault:secret/data/ddns#missing": "has no key missing",
+		"vault:secret/data/other#key":    "404",
+		"vault:secret/data/ddns":         "<path>#<key>",
+	} {
+		c := newSecretsTestConfig()
+		c.VaultConfig.Address = vault.URL
+		c.VaultConfig.Token = "vault-token"
+		c.CloudflareDNSProviderConfig.APIToken = ref
+
+		err := resolveSecrets(&c)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("got %v, wanted an error containing %s for %s", err, message, ref)
+		} else if strings.Contains(err.Error(), "vault-token") {
+			t.Errorf("got %s, wanted the token not to be part of the error", err)
+		}
+	}
+}
+
+// TestSendNotificationRedactsURL tests that errors of failed requests do not contain the path of the url
+func TestSendNotificationRedactsURL(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	url := s.URL + "/bot123456:secret-token/sendMessage"
+	s.Close()
+
+	err := sendNotification(context.Background(), "POST", url, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("got %s, wanted the url path to be redacted", err)
+	}
+}
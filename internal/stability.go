@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// StabilityConfig Config section governing when a newly obtained ip address is published
+type StabilityConfig struct {
+	// Number of consecutive attempts a new ip address has to be obtained before it is published
+	MinObservations int `yaml:"minObservations" envconfig:"DDNS_STABILITY_MIN_OBSERVATIONS" required:"false"`
+
+	// Go duration a new ip address has to be obtained consistently before it is published
+	MinDuration time.Duration `yaml:"minDuration" envconfig:"DDNS_STABILITY_MIN_DURATION" required:"false"`
+
+	// Go duration that has to pass between two updates of the same A record
+	MinUpdateInterval time.Duration `yaml:"minUpdateInterval" envconfig:"DDNS_STABILITY_MIN_UPDATE_INTERVAL" required:"false"`
+}
+
+var defaultStabilityConfig = &StabilityConfig{
+	MinObservations:   1,
+	MinDuration:       0,
+	MinUpdateInterval: 0,
+}
+
+const (
+	suppressedReasonUnstable          = "unstable"
+	suppressedReasonMinUpdateInterval = "min_update_interval"
+)
+
+// Observation Tracks for how long and how often the same ip address has been obtained in a row
+type Observation struct {
+	Address   string    `json:"address"`
+	FirstSeen time.Time `json:"firstSeen"`
+	Count     int       `json:"count"`
+}
+
+// StabilityPolicy Decides whether an obtained ip address is stable enough to be published
+type StabilityPolicy struct {
+	minObservations   int
+	minDuration       time.Duration
+	minUpdateInterval time.Duration
+	observation       Observation
+	lastUpdates       map[string]time.Time
+}
+
+// NewStabilityPolicy Returns an instance of StabilityPolicy based on the passed configuration
+func NewStabilityPolicy(config *StabilityConfig) *StabilityPolicy {
+	return &StabilityPolicy{
+		minObservations:   config.MinObservations,
+		minDuration:       config.MinDuration,
+		minUpdateInterval: config.MinUpdateInterval,
+		lastUpdates:       map[string]time.Time{},
+	}
+}
+
+// Configure Apply the thresholds of the passed configuration, the observation and the past updates are kept
+func (p *StabilityPolicy) Configure(config *StabilityConfig) {
+	p.minObservations = config.MinObservations
+	p.minDuration = config.MinDuration
+	p.minUpdateInterval = config.MinUpdateInterval
+}
+
+// Observe Register that the address was obtained at the passed time
+func (p *StabilityPolicy) Observe(address string, now time.Time) {
+	if p.observation.Address == address {
+		p.observation.Count++
+		return
+	}
+
+	p.observation = Observation{Address: address, FirstSeen: now, Count: 1}
+}
+
+// AllowUpdate Returns an empty string if the A record may be set to the observed address, or the reason why not
+func (p *StabilityPolicy) AllowUpdate(aRecord string, now time.Time) (string, string) {
+	o := p.observation
+	if o.Count < p.minObservations || now.Sub(o.FirstSeen) < p.minDuration {
+		return suppressedReasonUnstable, fmt.Sprintf("address %s was obtained %d times over %s, requires %d times over %s",
+			o.Address, o.Count, now.Sub(o.FirstSeen).Round(time.Second), p.minObservations, p.minDuration)
+	}
+
+	if last, ok := p.lastUpdates[aRecord]; ok && now.Sub(last) < p.minUpdateInterval {
+		return suppressedReasonMinUpdateInterval, fmt.Sprintf("last update was %s ago, requires %s between updates",
+			now.Sub(last).Round(time.Second), p.minUpdateInterval)
+	}
+
+	return "", ""
+}
+
+// RecordUpdate Register that the A record was updated at the passed time
+func (p *StabilityPolicy) RecordUpdate(aRecord string, now time.Time) {
+	p.lastUpdates[aRecord] = now
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStabilityPolicyMinObservations tests that an address has to be obtained the configured number of times in a row
+func TestStabilityPolicyMinObservations(t *testing.T) {
+	p := NewStabilityPolicy(&StabilityConfig{MinObservations: 3})
+	now := time.Now()
+
+	for _, a := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2", "10.0.0.1", "10.0.0.1"} {
+		p.Observe(a, now)
+		if reason, _ := p.AllowUpdate("example.com", now); reason != suppressedReasonUnstable {
+			t.Errorf("got reason %q after observing %s, wanted %q", reason, a, suppressedReasonUnstable)
+		}
+	}
+
+	p.Observe("10.0.0.1", now)
+	if reason, _ := p.AllowUpdate("example.com", now); reason != "" {
+		t.Errorf("got reason %q, wanted update to be allowed", reason)
+	}
+}
+
+// TestStabilityPolicyMinDuration tests that an address has to be obtained consistently for the configured duration
+func TestStabilityPolicyMinDuration(t *testing.T) {
+	p := NewStabilityPolicy(&StabilityConfig{MinObservations: 1, MinDuration: time.Minute})
+	now := time.Now()
+
+	p.Observe("10.0.0.1", now)
+	if reason, _ := p.AllowUpdate("example.com", now.Add(30*time.Second)); reason != suppressedReasonUnstable {
+		t.Errorf("got reason %q, wanted %q", reason, suppressedReasonUnstable)
+	}
+	if reason, _ := p.AllowUpdate("example.com", now.Add(time.Minute)); reason != "" {
+		t.Errorf("got reason %q, wanted update to be allowed", reason)
+	}
+}
+
+// TestStabilityPolicyMinUpdateInterval tests that the same record is not updated more often than configured
+func TestStabilityPolicyMinUpdateInterval(t *testing.T) {
+	p := NewStabilityPolicy(&StabilityConfig{MinObservations: 1, MinUpdateInterval: time.Hour})
+	now := time.Now()
+
+	p.Observe("10.0.0.1", now)
+	p.RecordUpdate("example.com", now)
+	if reason, _ := p.AllowUpdate("example.com", now.Add(time.Minute)); reason != suppressedReasonMinUpdateInterval {
+		t.Errorf("got reason %q, wanted %q", reason, suppressedReasonMinUpdateInterval)
+	}
+	if reason, _ := p.AllowUpdate("www.example.com", now.Add(time.Minute)); reason != "" {
+		t.Errorf("got reason %q, wanted update of other record to be allowed", reason)
+	}
+}
+
+// TestSyncerSuppressesFlappingAddress tests that the Syncer only publishes an address once it is stable
+func TestSyncerSuppressesFlappingAddress(t *testing.T) {
+	c := defaultConfig
+	c.StabilityConfig.MinObservations = 2
+	i := &fakeIPAddressProvider{address: "10.0.0.3"}
+	d := newFakeDNSProvider()
+	s := NewSyncer(&c, i, d, false)
+
+	for _, a := range []string{"10.0.0.3", "10.0.0.4", "10.0.0.3"} {
+		i.address = a
+		if err := s.Sync(); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	if len(d.sets) != 0 {
+		t.Fatalf("got %d updates while flapping, wanted none", len(d.sets))
+	}
+
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(d.sets) != 2 {
+		t.Errorf("got %d updates, wanted 2", len(d.sets))
+	}
+}
+
+// TestSyncerStabilityWithState tests that observations are persisted in the state file between syncers
+func TestSyncerStabilityWithState(t *testing.T) {
+	c := newStateTestConfig(t)
+	c.StabilityConfig.MinObservations = 2
+	d := newFakeDNSProvider()
+
+	for n := 0; n < 2; n++ {
+		if err := NewSyncer(c, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false).Sync(); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	if len(d.sets) != 2 {
+		t.Errorf("got %d updates, wanted 2", len(d.sets))
+	}
+}
+
+// TestSyncerStabilityReconfigure tests that a reload keeps the pending observation and the past updates
+func TestSyncerStabilityReconfigure(t *testing.T) {
+	c := defaultConfig
+	c.StabilityConfig.MinObservations = 2
+	c.StabilityConfig.MinUpdateInterval = time.Hour
+	i := &fakeIPAddressProvider{address: "10.0.0.3"}
+	d := newFakeDNSProvider()
+	s := NewSyncer(&c, i, d, false)
+
+	for n := 0; n < 2; n++ {
+		if err := s.Sync(); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	if len(d.sets) != 2 {
+		t.Fatalf("got %d updates, wanted 2", len(d.sets))
+	}
+
+	i.address = "10.0.0.4"
+	for n := 0; n < 2; n++ {
+		s.Reconfigure(&c, i, d)
+		if err := s.Sync(); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	if len(d.sets) != 2 {
+		t.Errorf("got %d updates after reloading, wanted the minimum update interval to suppress them", len(d.sets))
+	}
+
+	c.StabilityConfig.MinUpdateInterval = 0
+	s.Reconfigure(&c, i, d)
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(d.sets) != 4 {
+		t.Errorf("got %d updates, wanted the observations before the reload to count", len(d.sets))
+	}
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateStoreConfig Config section governing the on-disk state file
+type StateStoreConfig struct {
+	// Switch to enable or disable the state file
+	Enable bool `yaml:"enable" envconfig:"DDNS_STATE_ENABLE" required:"false"`
+
+	// Relative or absolute path to the state file
+	Path string `yaml:"path" envconfig:"DDNS_STATE_PATH" required:"false"`
+
+	// Go duration after which the DNS provider is queried even if the ip address did not change
+	ForceReconcileInterval time.Duration `yaml:"forceReconcileInterval" envconfig:"DDNS_STATE_FORCE_RECONCILE_INTERVAL" required:"false"`
+}
+
+var defaultStateStoreConfig = &StateStoreConfig{
+	Enable:                 false,
+	Path:                   "./ddns.state",
+	ForceReconcileInterval: 1 * time.Hour,
+}
+
+// StateRecord The last known state of a single A record
+type StateRecord struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// State The state persisted between synchronizations
+type State struct {
+	// Fingerprint of the configuration the state was created with
+	Fingerprint string `json:"fingerprint"`
+
+	// Last ip address obtained from the IPAddressProvider
+	Address string `json:"address"`
+
+	// Time at which the ip address was last obtained
+	DetectedAt time.Time `json:"detectedAt"`
+
+	// Time at which the A records were last read from the DNSProvider
+	ReconciledAt time.Time `json:"reconciledAt"`
+
+	// Last known A records by name
+	Records map[string]StateRecord `json:"records"`
+
+	// Tracks how often the current ip address has been obtained in a row
+	Observation *Observation `json:"observation,omitempty"`
+}
+
+// StateStore Reads and writes the State from and to a json file
+type StateStore struct {
+	path string
+}
+
+// NewStateStore Returns an instance of StateStore based on the passed configuration
+func NewStateStore(config *StateStoreConfig) *StateStore {
+	return &StateStore{
+		path: config.Path,
+	}
+}
+
+// Load Read the state from disk, returns an empty state if the file does not exist yet
+func (s *StateStore) Load() (*State, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{Records: map[string]StateRecord{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+	if state.Records == nil {
+		state.Records = map[string]StateRecord{}
+	}
+
+	return &state, nil
+}
+
+// Save Atomically replace the state file by writing to a temporary file in the same directory and renaming it
+func (s *StateStore) Save(state *State) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, b, 0600)
+}
+
+// writeFileAtomic Write data to a temporary file next to path, sync it and rename it to path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	// Remove the temporary file if anything goes wrong before the rename
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newStateTestConfig(t *testing.T) *Config {
+	c := defaultConfig
+	c.StateStoreConfig.Enable = true
+	c.StateStoreConfig.Path = filepath.Join(t.TempDir(), "ddns.state")
+	c.CloudflareDNSProviderConfig.Enable = true
+	return &c
+}
+
+// TestStateStoreLoadMissing tests that a missing state file results in an empty state
+func TestStateStoreLoadMissing(t *testing.T) {
+	s := NewStateStore(&StateStoreConfig{Path: filepath.Join(t.TempDir(), "missing")})
+	state, err := s.Load()
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if state.Address != "" || len(state.Records) != 0 {
+		t.Errorf("expected empty state, got %+v", state)
+	}
+}
+
+// TestStateStoreSaveLoad tests that a saved state can be loaded again and no temporary files remain
+func TestStateStoreSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStateStore(&StateStoreConfig{Path: filepath.Join(dir, "ddns.state")})
+
+	want := &State{Address: "10.0.0.1", Records: map[string]StateRecord{"example.com": {ID: "1", Content: "10.0.0.1"}}}
+	if err := s.Save(want); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if got.Address != want.Address || got.Records["example.com"].ID != "1" {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the state file, got %d entries", len(entries))
+	}
+}
+
+// TestSyncerSkipsProviderWithState tests that the DNS provider is only queried when the ip address changes
+func TestSyncerSkipsProviderWithState(t *testing.T) {
+	c := newStateTestConfig(t)
+	i := &fakeIPAddressProvider{address: "10.0.0.3"}
+	d := newFakeDNSProvider()
+
+	s := NewSyncer(c, i, d, false)
+	for n := 0; n < 3; n++ {
+		if err := s.Sync(); err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+	if d.gets != 1 {
+		t.Errorf("got %d provider queries, wanted 1", d.gets)
+	}
+
+	i.address = "10.0.0.4"
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if d.gets != 2 || len(d.sets) != 4 {
+		t.Errorf("got %d provider queries and %d updates, wanted 2 and 4", d.gets, len(d.sets))
+	}
+}
+
+// TestSyncerForcedReconcile tests that the DNS provider is queried once the forced reconcile interval elapsed
+func TestSyncerForcedReconcile(t *testing.T) {
+	c := newStateTestConfig(t)
+	d := newFakeDNSProvider()
+	s := NewSyncer(c, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false)
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	state, _ := s.stateStore.Load()
+	state.ReconciledAt = time.Now().Add(-2 * c.StateStoreConfig.ForceReconcileInterval)
+	_ = s.stateStore.Save(state)
+
+	if err := s.Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if d.gets != 2 {
+		t.Errorf("got %d provider queries, wanted 2", d.gets)
+	}
+}
+
+// TestConfigFingerprint tests that only changes to the enabled provider sections change the fingerprint
+func TestConfigFingerprint(t *testing.T) {
+	c := newStateTestConfig(t)
+	fingerprint := c.Fingerprint()
+
+	c.WaitInterval = time.Hour
+	c.MQTTConfig.Enable = true
+	c.NotificationsConfig.Webhook.URL = "https://example.com/hook"
+	c.DynDNS2DNSProviderConfig.ARecords = []string{"example.com"}
+	if got := c.Fingerprint(); got != fingerprint {
+		t.Errorf("got fingerprint %s, wanted %s to be kept for unrelated changes", got, fingerprint)
+	}
+
+	c.CloudflareDNSProviderConfig.ZoneID = "other"
+	if got := c.Fingerprint(); got == fingerprint {
+		t.Error("got the same fingerprint, wanted a change of the dns provider to change it")
+	}
+}
+
+// TestSyncerConfigChange tests that a changed config invalidates the state
+func TestSyncerConfigChange(t *testing.T) {
+	c := newStateTestConfig(t)
+	d := newFakeDNSProvider()
+	if err := NewSyncer(c, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	c.CloudflareDNSProviderConfig.ARecords = []string{"example.com"}
+	if err := NewSyncer(c, &fakeIPAddressProvider{address: "10.0.0.3"}, d, false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if d.gets != 2 {
+		t.Errorf("got %d provider queries, wanted 2", d.gets)
+	}
+}
@@ -1,5 +1,7 @@
 package internal
 
+import "context"
+
 type StaticIPAddressProviderConfig struct {
 	// Switch to enable or disable this provider
 	Enable bool `yaml:"enable" envconfig:"DDNS_STATIC_PROVIDER_ENABLE" required:"false"`
@@ -25,6 +27,6 @@ func NewStaticIPAddressProvider(config *StaticIPAddressProviderConfig) *StaticIP
 }
 
 // GetIPAddress Returns the static ip address passed via configuration
-func (s *StaticIPAddressProvider) GetIPAddress() (*string, error) {
+func (s *StaticIPAddressProvider) GetIPAddress(_ context.Context) (*string, error) {
 	return &s.address, nil
 }
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"testing"
 )
 
@@ -24,7 +25,7 @@ func TestStaticIPAddressProviderGetIPAddress(t *testing.T) {
 	provider := NewStaticIPAddressProvider(defaultStaticIPAddressProviderConfig)
 
 	// Call GetIPAddress() method
-	got, err := provider.GetIPAddress()
+	got, err := provider.GetIPAddress(context.Background())
 
 	// Verify that the returned ip address is the same as the one passed via configuration
 	want := "127.0.0.1"
@@ -0,0 +1,545 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SyncReport The outcome of a single synchronization
+type SyncReport struct {
+	StartedAt  time.Time       `json:"startedAt"`
+	FinishedAt time.Time       `json:"finishedAt"`
+	DryRun     bool            `json:"dryRun"`
+	IPAddress  string          `json:"ipAddress,omitempty"`
+	Skipped    bool            `json:"skipped"`
+	Records    []PlannedRecord `json:"records"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// Syncer Synchronizes the A records of a DNSProvider with the ip address of an IPAddressProvider
+type Syncer struct {
+	ipAddressProvider      IPAddressProvider
+	ipAddressProviderName  string
+	dnsProvider            DNSProvider
+	dnsProviderName        string
+	dryRun                 bool
+	stateStore             *StateStore
+	fingerprint            string
+	forceReconcileInterval time.Duration
+	stabilityPolicy        *StabilityPolicy
+	notifications          *Notifications
+	hooks                  *Hooks
+	lastAddress            string
+	failures               int
+
+	syncMu      sync.Mutex
+	mu          sync.Mutex
+	lastReport  *SyncReport
+	lastSuccess time.Time
+	records     map[string]RecordAddressMapping
+	health      map[string]*ProviderHealth
+}
+
+// NewSyncer Returns an instance of Syncer based on the passed configuration and providers
+func NewSyncer(c *Config, i IPAddressProvider, d DNSProvider, dryRun bool) *Syncer {
+	s := &Syncer{
+		dryRun:  dryRun,
+		records: map[string]RecordAddressMapping{},
+		health:  map[string]*ProviderHealth{},
+	}
+	s.configure(c, i, d)
+
+	return s
+}
+
+// Reconfigure Replace the configuration and providers, waiting for a running synchronization to finish
+func (s *Syncer) Reconfigure(c *Config, i IPAddressProvider, d DNSProvider) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	s.configure(c, i, d)
+}
+
+// configure Set the providers and everything derived from the configuration, the history of past synchronizations is kept
+func (s *Syncer) configure(c *Config, i IPAddressProvider, d DNSProvider) {
+	s.mu.Lock()
+	s.ipAddressProvider = i
+	s.dnsProvider = d
+	s.mu.Unlock()
+	s.ipAddressProviderName = providerName(i)
+	s.dnsProviderName = providerName(d)
+	if s.stabilityPolicy == nil {
+		s.stabilityPolicy = NewStabilityPolicy(&c.StabilityConfig)
+	} else {
+		s.stabilityPolicy.Configure(&c.StabilityConfig)
+	}
+	s.notifications = NewNotifications(&c.NotificationsConfig)
+	s.hooks = NewHooks(&c.HooksConfig)
+	s.stateStore = nil
+	s.fingerprint = ""
+	s.forceReconcileInterval = 0
+
+	if c.StateStoreConfig.Enable {
+		log.Debug().Msgf("Using state file at %s", c.StateStoreConfig.Path)
+		s.stateStore = NewStateStore(&c.StateStoreConfig)
+		s.fingerprint = c.Fingerprint()
+		s.forceReconcileInterval = c.StateStoreConfig.ForceReconcileInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, name := range map[string]string{"ipAddressProvider": s.ipAddressProviderName, "dnsProvider": s.dnsProviderName} {
+		if h, ok := s.health[key]; !ok || h.Name != name {
+			s.health[key] = &ProviderHealth{Name: name, Healthy: true}
+		}
+	}
+
+	// Forget A records that are no longer configured, including their info series
+	configured := map[string]bool{}
+	for _, name := range c.ARecords() {
+		configured[normalizeRecordName(name)] = true
+	}
+	for name := range s.records {
+		if !configured[normalizeRecordName(name)] {
+			delete(s.records, name)
+			deleteInfoGauge(DNSARecordInfoGauge, "a_record", name)
+		}
+	}
+}
+
+// normalizeRecordName Returns the lower case name without a trailing dot
+func normalizeRecordName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// Sync Updates the A records if required, or only logs the required updates in dry run mode
+func (s *Syncer) Sync() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	ctx, span := startSpan(context.Background(), "sync", attribute.Bool("ddns.dry_run", s.dryRun))
+	report := &SyncReport{StartedAt: time.Now(), DryRun: s.dryRun, Records: []PlannedRecord{}}
+	err := s.sync(ctx, report)
+	report.FinishedAt = time.Now()
+	span.SetAttributes(attribute.String("ddns.ip_address", report.IPAddress), attribute.Bool("ddns.skipped", report.Skipped))
+	s.trackFailures(ctx, err)
+	endSpan(span, err)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	s.lastReport = report
+	if err == nil {
+		s.lastSuccess = report.FinishedAt
+		LastSuccessfulSyncGauge.WithLabelValues().Set(float64(report.FinishedAt.Unix()))
+	}
+	s.mu.Unlock()
+
+	return err
+}
+
+// LastReport Returns the report of the most recent synchronization, or nil if there was none yet
+func (s *Syncer) LastReport() *SyncReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastReport
+}
+
+// LastSuccess Returns the time the last successful synchronization finished, or the zero time if there was none yet
+func (s *Syncer) LastSuccess() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastSuccess
+}
+
+// Providers Returns the ip address provider and dns provider currently in use
+func (s *Syncer) Providers() (IPAddressProvider, DNSProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ipAddressProvider, s.dnsProvider
+}
+
+// ProviderHealth Returns the outcome of the most recent calls to the providers
+func (s *Syncer) ProviderHealth() map[string]ProviderHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := map[string]ProviderHealth{}
+	for k, h := range s.health {
+		health[k] = *h
+	}
+	return health
+}
+
+// observeProvider Register the duration and outcome of a call to the provider with the key
+func (s *Syncer) observeProvider(key string, operation string, start time.Time, err error, errorClass string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.health[key]
+	ProviderRequestDurationHistogram.WithLabelValues(h.Name, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ErrorsCounter.WithLabelValues(errorClass).Inc()
+	}
+	h.record(err)
+}
+
+// Records Returns the last known A records sorted by name
+func (s *Syncer) Records() []RecordAddressMapping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []RecordAddressMapping{}
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ARecord < records[j].ARecord })
+
+	return records
+}
+
+// setRecord Remembers the current ip address of an A record
+func (s *Syncer) setRecord(id string, aRecord string, ipAddress string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.records[aRecord]; !ok || r.IPAddress != ipAddress {
+		setInfoGauge(DNSARecordInfoGauge, "ip_address", ipAddress, "a_record", aRecord)
+	}
+	s.records[aRecord] = RecordAddressMapping{ID: id, ARecord: aRecord, IPAddress: ipAddress}
+}
+
+func (s *Syncer) sync(ctx context.Context, report *SyncReport) error {
+	start := time.Now()
+	spanCtx, span := startSpan(ctx, "GetIPAddress", attribute.String("ddns.provider", s.ipAddressProviderName))
+	addressToSet, err := s.ipAddressProvider.GetIPAddress(spanCtx)
+	endSpan(span, err)
+	s.observeProvider("ipAddressProvider", "get_ip_address", start, err, errorClassIPAddressProvider)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Obtained ip address was %s", *addressToSet)
+	setInfoGauge(DetectedIPAddressInfoGauge, "ip_address", *addressToSet, "source", s.ipAddressProviderName)
+	report.IPAddress = *addressToSet
+
+	state := s.loadState()
+	if state != nil && state.Observation != nil {
+		s.stabilityPolicy.observation = *state.Observation
+	}
+	s.stabilityPolicy.Observe(*addressToSet, time.Now())
+	if state != nil {
+		state.Observation = &s.stabilityPolicy.observation
+	}
+
+	previousAddress := s.lastAddress
+	if state != nil && state.Address != "" {
+		previousAddress = state.Address
+	}
+	if previousAddress != "" && previousAddress != *addressToSet {
+		s.notify(ctx, Event{
+			Type:       EventAddressChanged,
+			Message:    fmt.Sprintf("ip address changed from %s to %s", previousAddress, *addressToSet),
+			OldAddress: previousAddress,
+			NewAddress: *addressToSet,
+		})
+	}
+	s.lastAddress = *addressToSet
+
+	if state != nil && s.upToDate(state, *addressToSet) {
+		log.Info().Msgf("Ip address unchanged since last reconciliation at %s, skipping DNS provider", state.ReconciledAt.Format(time.RFC3339))
+		report.Skipped = true
+		for name, r := range state.Records {
+			s.setRecord(r.ID, name, r.Content)
+			report.Records = append(report.Records, PlannedRecord{ID: r.ID, ARecord: name, CurrentIPAddress: r.Content, DesiredIPAddress: *addressToSet})
+		}
+		sort.Slice(report.Records, func(i, j int) bool { return report.Records[i].ARecord < report.Records[j].ARecord })
+		state.DetectedAt = time.Now()
+		s.saveState(state)
+		return nil
+	}
+
+	start = time.Now()
+	spanCtx, span = startSpan(ctx, "GetARecordAddresses", attribute.String("ddns.provider", s.dnsProviderName))
+	p, err := createPlan(spanCtx, *addressToSet, s.dnsProvider)
+	endSpan(span, err)
+	s.observeProvider("dnsProvider", "get_a_record_addresses", start, err, errorClassDNSProviderRead)
+	if err != nil {
+		return err
+	}
+	report.Records = p.Records
+
+	if state != nil {
+		records := map[string]StateRecord{}
+		for _, r := range p.Records {
+			records[r.ARecord] = StateRecord{ID: r.ID, Content: r.CurrentIPAddress, UpdatedAt: state.Records[r.ARecord].UpdatedAt}
+			if !records[r.ARecord].UpdatedAt.IsZero() {
+				s.stabilityPolicy.RecordUpdate(r.ARecord, records[r.ARecord].UpdatedAt)
+			}
+		}
+		state.Fingerprint = s.fingerprint
+		state.Address = *addressToSet
+		state.DetectedAt = time.Now()
+		state.Records = records
+	}
+
+	s.applyStabilityPolicy(p)
+
+	if s.dryRun {
+		for _, r := range p.Records {
+			s.setRecord(r.ID, r.ARecord, r.CurrentIPAddress)
+		}
+		return logPlan(p)
+	}
+
+	updates := p.Updates()
+	if len(updates) > 0 {
+		if err := s.hooks.RunPreUpdate(ctx, newHookPayload(hookPhasePreUpdate, updates, nil, nil)); err != nil {
+			s.saveState(state)
+			return err
+		}
+	}
+
+	var updateErr error
+	updated := map[string]bool{}
+	batch, isBatch := s.dnsProvider.(BatchDNSProvider)
+	for _, r := range p.Records {
+		if r.Suppressed != "" {
+			s.setRecord(r.ID, r.ARecord, r.CurrentIPAddress)
+		} else if r.Update && isBatch {
+			// Set below in a single request
+			continue
+		} else if r.Update {
+			if updateErr = s.setRecordAddress(ctx, r, state); updateErr != nil {
+				break
+			}
+			updated[r.ARecord] = true
+		} else {
+			log.Info().Msgf("Ip address of A record matched obtained address, no update required")
+			s.setRecord(r.ID, r.ARecord, r.CurrentIPAddress)
+		}
+	}
+
+	if isBatch && len(updates) > 0 {
+		updateErr = s.setRecordAddresses(ctx, batch, updates, state, updated)
+	}
+
+	if len(updates) > 0 {
+		s.hooks.RunPostUpdate(ctx, newHookPayload(hookPhasePostUpdate, updates, updated, updateErr))
+	}
+	if updateErr != nil {
+		s.saveState(state)
+		return updateErr
+	}
+
+	if state != nil {
+		state.ReconciledAt = time.Now()
+		s.saveState(state)
+	}
+
+	return nil
+}
+
+// setRecordAddress Sets the A record to its desired ip address and records the update
+func (s *Syncer) setRecordAddress(ctx context.Context, r PlannedRecord, state *State) error {
+	log.Info().Msg("Ip address of A record did not match obtained address")
+	m := RecordAddressMapping{ID: r.ID, ARecord: r.ARecord, IPAddress: r.CurrentIPAddress}
+	start := time.Now()
+	spanCtx, span := startSpan(ctx, "SetARecordAddress",
+		attribute.String("ddns.provider", s.dnsProviderName),
+		attribute.String("ddns.a_record", r.ARecord),
+		attribute.String("ddns.ip_address", r.DesiredIPAddress))
+	err := s.dnsProvider.SetARecordAddress(spanCtx, r.DesiredIPAddress, m)
+	endSpan(span, err)
+	s.observeProvider("dnsProvider", "set_a_record_address", start, err, errorClassDNSProviderWrite)
+	if err != nil {
+		return err
+	}
+
+	s.recordUpdate(ctx, r, state)
+	return nil
+}
+
+// setRecordAddresses Sets the A records to their desired ip address in a single request and records the updates that
+// succeeded in updated
+func (s *Syncer) setRecordAddresses(ctx context.Context, b BatchDNSProvider, updates []PlannedRecord, state *State, updated map[string]bool) error {
+	log.Info().Msgf("Ip address of %d A records did not match obtained address", len(updates))
+	var ms []RecordAddressMapping
+	for _, r := range updates {
+		ms = append(ms, RecordAddressMapping{ID: r.ID, ARecord: r.ARecord, IPAddress: r.CurrentIPAddress})
+	}
+	start := time.Now()
+	spanCtx, span := startSpan(ctx, "SetARecordAddresses",
+		attribute.String("ddns.provider", s.dnsProviderName),
+		attribute.Int("ddns.a_records", len(ms)),
+		attribute.String("ddns.ip_address", updates[0].DesiredIPAddress))
+	errs := b.SetARecordAddresses(spanCtx, updates[0].DesiredIPAddress, ms)
+	err := errors.Join(errs...)
+	endSpan(span, err)
+	s.observeProvider("dnsProvider", "set_a_record_address", start, err, errorClassDNSProviderWrite)
+
+	for i, r := range updates {
+		if i < len(errs) && errs[i] != nil {
+			continue
+		}
+		s.recordUpdate(ctx, r, state)
+		updated[r.ARecord] = true
+	}
+
+	return err
+}
+
+// recordUpdate Records that the A record was set to its desired ip address
+func (s *Syncer) recordUpdate(ctx context.Context, r PlannedRecord, state *State) {
+	s.setRecord(r.ID, r.ARecord, r.DesiredIPAddress)
+	DNSRecordUpdatesCounter.WithLabelValues(r.ARecord, s.dnsProviderName).Inc()
+	LastChangeGauge.WithLabelValues(r.ARecord).SetToCurrentTime()
+	s.notify(ctx, Event{
+		Type:       EventRecordUpdated,
+		Message:    fmt.Sprintf("A record %s updated from %s to %s", r.ARecord, r.CurrentIPAddress, r.DesiredIPAddress),
+		OldAddress: r.CurrentIPAddress,
+		NewAddress: r.DesiredIPAddress,
+		ARecord:    r.ARecord,
+	})
+	s.stabilityPolicy.RecordUpdate(r.ARecord, time.Now())
+	if state != nil {
+		state.Records[r.ARecord] = StateRecord{ID: r.ID, Content: r.DesiredIPAddress, UpdatedAt: time.Now()}
+	}
+}
+
+// trackFailures Counts consecutive failures and notifies once the threshold is reached and once it recovered
+func (s *Syncer) trackFailures(ctx context.Context, err error) {
+	threshold := s.notifications.failureThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	if err != nil {
+		s.failures++
+		if s.failures == threshold {
+			s.notify(ctx, Event{
+				Type:     EventSyncFailed,
+				Message:  fmt.Sprintf("synchronization failed %d times in a row: %s", s.failures, err),
+				Failures: s.failures,
+				Error:    err.Error(),
+			})
+		}
+		return
+	}
+
+	if s.failures >= threshold {
+		s.notify(ctx, Event{
+			Type:     EventRecovered,
+			Message:  fmt.Sprintf("synchronization recovered after %d failed attempts", s.failures),
+			Failures: s.failures,
+		})
+	}
+	s.failures = 0
+}
+
+// notify Dispatches the event to the notifiers unless in dry run mode
+func (s *Syncer) notify(ctx context.Context, e Event) {
+	if s.dryRun {
+		return
+	}
+	s.notifications.Dispatch(ctx, e)
+}
+
+// applyStabilityPolicy Marks the updates of the plan that are suppressed by the StabilityPolicy
+func (s *Syncer) applyStabilityPolicy(p *Plan) {
+	now := time.Now()
+	for idx, r := range p.Records {
+		if !r.Update {
+			continue
+		}
+
+		reason, detail := s.stabilityPolicy.AllowUpdate(r.ARecord, now)
+		if reason == "" {
+			continue
+		}
+
+		log.Info().Msgf("Suppressing update of A record %s from %s to %s: %s", r.ARecord, r.CurrentIPAddress, r.DesiredIPAddress, detail)
+		SuppressedUpdatesCounter.WithLabelValues(r.ARecord, reason).Inc()
+		p.Records[idx].Update = false
+		p.Records[idx].Suppressed = reason
+	}
+}
+
+// upToDate Returns true if the state shows all A records set to the address and no reconciliation is due
+func (s *Syncer) upToDate(state *State, address string) bool {
+	if state.Fingerprint != s.fingerprint || state.Address != address || len(state.Records) == 0 {
+		return false
+	}
+
+	if time.Since(state.ReconciledAt) >= s.forceReconcileInterval {
+		return false
+	}
+
+	for _, r := range state.Records {
+		if r.Content != address {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadState Returns the persisted state, or nil if there is no state store or in dry run mode
+func (s *Syncer) loadState() *State {
+	if s.stateStore == nil || s.dryRun {
+		return nil
+	}
+
+	state, err := s.stateStore.Load()
+	if err != nil {
+		log.Error().Msgf("Could not load state file, reconciling with DNS provider: %s", err)
+		ErrorsCounter.WithLabelValues(errorClassState).Inc()
+		return &State{Records: map[string]StateRecord{}}
+	}
+
+	return state
+}
+
+// saveState Persists the state if there is a state store
+func (s *Syncer) saveState(state *State) {
+	if s.stateStore == nil || state == nil {
+		return
+	}
+
+	if err := s.stateStore.Save(state); err != nil {
+		log.Error().Msgf("Could not write state file: %s", err)
+		ErrorsCounter.WithLabelValues(errorClassState).Inc()
+	}
+}
+
+// logPlan Logs the updates of the plan as a dry run
+func logPlan(p *Plan) error {
+	for _, r := range p.Records {
+		if r.Suppressed != "" {
+			log.Info().Msgf("Dry run: would suppress update of A record %s from %s to %s (%s)", r.ARecord, r.CurrentIPAddress, r.DesiredIPAddress, r.Suppressed)
+		} else if r.Update {
+			log.Info().Msgf("Dry run: would set A record %s from %s to %s", r.ARecord, r.CurrentIPAddress, r.DesiredIPAddress)
+		}
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	log.Info().RawJSON("plan", b).Msgf("Dry run: %d of %d A records would be updated", len(p.Updates()), len(p.Records))
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingConfig Config section governing OpenTelemetry tracing
+type TracingConfig struct {
+	// Switch to enable or disable exporting traces
+	Enable bool `yaml:"enable" envconfig:"DDNS_TRACING_ENABLE" required:"false"`
+
+	// Host and port of the OTLP/HTTP collector, e.g. localhost:4318
+	Endpoint string `yaml:"endpoint" envconfig:"DDNS_TRACING_ENDPOINT" required:"false"`
+
+	// Switch to use http instead of https when exporting traces
+	Insecure bool `yaml:"insecure" envconfig:"DDNS_TRACING_INSECURE" required:"false"`
+
+	// Service name attached to all exported spans
+	ServiceName string `yaml:"serviceName" envconfig:"DDNS_TRACING_SERVICE_NAME" required:"false"`
+
+	// Fraction of sync cycles to trace between 0 and 1
+	SampleRatio float64 `yaml:"sampleRatio" envconfig:"DDNS_TRACING_SAMPLE_RATIO" required:"false"`
+}
+
+var defaultTracingConfig = &TracingConfig{
+	Enable:      false,
+	Endpoint:    "localhost:4318",
+	Insecure:    false,
+	ServiceName: "ddns",
+	SampleRatio: 1,
+}
+
+const tracerName = "ddns"
+
+var tracerProvider *sdktrace.TracerProvider
+
+// InitTracing Installs a global tracer provider exporting via OTLP if enabled
+func InitTracing(config *TracingConfig, version string) error {
+	if !config.Enable {
+		return nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return err
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return nil
+}
+
+// ShutdownTracing Flushes all pending spans and stops the tracer provider installed by InitTracing
+func ShutdownTracing() {
+	if tracerProvider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		log.Error().Msgf("Could not flush traces: %s", err)
+	}
+	tracerProvider = nil
+}
+
+// startSpan Starts a span as child of the span in ctx using the global tracer provider
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan Records the error on the span if there is one and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
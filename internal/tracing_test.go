@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestSyncSpans tests that a sync cycle creates a root span with a child span per provider call
+func TestSyncSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	if err := NewSyncer(&defaultConfig, &fakeIPAddressProvider{address: "10.0.0.3"}, newFakeDNSProvider(), false).Sync(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 5 {
+		t.Fatalf("got %d spans, wanted 5", len(spans))
+	}
+
+	root := spans[len(spans)-1]
+	if root.Name() != "sync" {
+		t.Fatalf("got root span %s, wanted sync", root.Name())
+	}
+
+	names := map[string]int{}
+	for _, s := range spans[:len(spans)-1] {
+		if s.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the sync span", s.Name())
+		}
+		names[s.Name()]++
+	}
+	if names["GetIPAddress"] != 1 || names["GetARecordAddresses"] != 1 || names["SetARecordAddress"] != 2 {
+		t.Errorf("unexpected child spans %v", names)
+	}
+}
+
+// TestInitTracingDisabled tests that no tracer provider is installed when tracing is disabled
+func TestInitTracingDisabled(t *testing.T) {
+	if err := InitTracing(defaultTracingConfig, "0.0.0"); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if tracerProvider != nil {
+		t.Error("expected no tracer provider")
+	}
+	ShutdownTracing()
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"regexp"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type URLIPAddressProviderConfig struct {
@@ -31,7 +33,7 @@ type URLIPAddressProviderConfig struct {
 	Username string `yaml:"username" envconfig:"DDNS_URL_PROVIDER_USERNAME" required:"false"`
 
 	// BasicAuth password
-	Password string `yaml:"password" envconfig:"DDNS_URL_PROVIDER_PASSWORD" required:"false"`
+	Password string `yaml:"password" envconfig:"DDNS_URL_PROVIDER_PASSWORD" required:"false" secret:"true"`
 }
 
 var defaultURLIPAddressProviderConfig = &URLIPAddressProviderConfig{
@@ -66,7 +68,7 @@ func NewURLIPAddressProvider(config *URLIPAddressProviderConfig) *URLIPAddressPr
 }
 
 // GetIPAddress Returns the ip address returned by the url, and parsed using the regex provided via the configuration
-func (u *URLIPAddressProvider) GetIPAddress() (*string, error) {
+func (u *URLIPAddressProvider) GetIPAddress(ctx context.Context) (*string, error) {
 	// Make request
 	proto := "http"
 	if u.https {
@@ -79,9 +81,9 @@ func (u *URLIPAddressProvider) GetIPAddress() (*string, error) {
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: u.insecureSkipVerify},
 	}
 
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: otelhttp.NewTransport(tr)}
 
-	req, err := http.NewRequest("GET", requestURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,757 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted Replaces the values of secret config fields when the config is shown
+const redacted = "REDACTED"
+
+// yamlErrorLine Matches the line number yaml.v3 prefixes its errors with
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// hostnameLabel Matches a single label of a hostname
+var hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9_])?$`)
+
+// ConfigError A single invalid setting of the config
+type ConfigError struct {
+	// Path of the setting in the config file, e.g. cloudflareDNSProvider.aRecords[0]
+	Path string `json:"path,omitempty"`
+
+	// Line of the setting in the config file, 0 if unknown
+	Line int `json:"line,omitempty"`
+
+	Message string `json:"message"`
+}
+
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", e.Line)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, "%s: ", e.Path)
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ConfigErrors All invalid settings of the config
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// configValidator Collects the errors of a config
+type configValidator struct {
+	errs ConfigErrors
+}
+
+func (v *configValidator) add(path string, format string, args ...any) {
+	v.errs = append(v.errs, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *configValidator) required(path string, value string) {
+	if value == "" {
+		v.add(path, "is required")
+	}
+}
+
+func (v *configValidator) positive(path string, d time.Duration) {
+	if d <= 0 {
+		v.add(path, "must be positive, got %s", d)
+	}
+}
+
+func (v *configValidator) notNegative(path string, d time.Duration) {
+	if d < 0 {
+		v.add(path, "must not be negative, got %s", d)
+	}
+}
+
+// url Validates that the value is an absolute url with one of the schemes
+func (v *configValidator) url(path string, value string, schemes ...string) {
+	if value == "" {
+		v.add(path, "is required")
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add(path, "is not a valid url: %s", err)
+		return
+	}
+	for _, s := range schemes {
+		if u.Scheme == s && u.Host != "" {
+			return
+		}
+	}
+	v.add(path, "must be a %s url, got %q", strings.Join(schemes, " or "), value)
+}
+
+// hostPort Validates that the value is a host and port
+func (v *configValidator) hostPort(path string, value string) {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || host == "" {
+		v.add(path, "%q must have the form host:port", value)
+		return
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		v.add(path, "%q is not a valid port", port)
+	}
+}
+
+// hostnames Validates that the list is not empty and only contains valid hostnames, the first label may be a wildcard
+func (v *configValidator) hostnames(path string, values []string) {
+	if len(values) == 0 {
+		v.add(path, "at least one record is required")
+	}
+	for i, h := range values {
+		if !validHostname(h) {
+			v.add(fmt.Sprintf("%s[%d]", path, i), "%q is not a valid hostname", h)
+		}
+	}
+}
+
+// inZone Validates that the valid hostnames of the list are part of the zone, invalid ones are reported by hostnames
+func (v *configValidator) inZone(path string, values []string, zone string) {
+	if !validHostname(zone) {
+		return
+	}
+	for i, h := range values {
+		if validHostname(h) && !dns.IsSubDomain(dns.Fqdn(zone), dns.Fqdn(h)) {
+			v.add(fmt.Sprintf("%s[%d]", path, i), "%q is not part of the zone %s", h, zone)
+		}
+	}
+}
+
+// events Validates that the list only contains known event types
+func (v *configValidator) events(path string, events []string) {
+	for i, e := range events {
+		switch EventType(e) {
+		case EventAddressChanged, EventRecordUpdated, EventSyncFailed, EventRecovered:
+		default:
+			v.add(fmt.Sprintf("%s[%d]", path, i), "unknown event type %q", e)
+		}
+	}
+}
+
+// exactlyOne Validates that exactly one of the providers, keyed by config section, is enabled
+func (v *configValidator) exactlyOne(kind string, enabled map[string]bool) {
+	var names []string
+	for name, e := range enabled {
+		if e {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	switch len(names) {
+	case 0:
+		v.add("", "no %s was configured and enabled", kind)
+	case 1:
+	default:
+		for _, name := range names {
+			v.add(name+".enable", "only one %s may be enabled, got %s", kind, strings.Join(names, ", "))
+		}
+	}
+}
+
+// Validate returns ConfigErrors describing every setting of the config that cannot be used
+func (c *Config) Validate() error {
+	v := &configValidator{}
+
+	v.positive("waitInterval", c.WaitInterval)
+	v.positive("retryInterval", c.RetryInterval)
+
+	if c.MetricsServerConfig.Enable {
+		if p, err := strconv.Atoi(c.MetricsServerConfig.Port); err != nil || p < 0 || p > 65535 {
+			v.add("metricsServer.port", "%q is not a valid port", c.MetricsServerConfig.Port)
+		}
+	}
+
+	v.positive("hooks.timeout", c.HooksConfig.Timeout)
+	for _, phase := range []struct {
+		name  string
+		hooks []HookConfig
+	}{
+		{"preUpdate", c.HooksConfig.PreUpdate},
+		{"postUpdate", c.HooksConfig.PostUpdate},
+	} {
+		for i, h := range phase.hooks {
+			path := fmt.Sprintf("hooks.%s[%d]", phase.name, i)
+			if len(h.Command) == 0 || h.Command[0] == "" {
+				v.add(path+".command", "is required")
+			}
+			v.notNegative(path+".timeout", h.Timeout)
+		}
+	}
+
+	c.validateNotifications(v)
+
+	if c.TracingConfig.Enable {
+		v.required("tracing.endpoint", c.TracingConfig.Endpoint)
+		if c.TracingConfig.SampleRatio < 0 || c.TracingConfig.SampleRatio > 1 {
+			v.add("tracing.sampleRatio", "must be between 0 and 1, got %g", c.TracingConfig.SampleRatio)
+		}
+	}
+
+	if c.MQTTConfig.Enable {
+		v.url("mqtt.broker", c.MQTTConfig.Broker, "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss")
+		v.required("mqtt.clientID", c.MQTTConfig.ClientID)
+		v.required("mqtt.topicPrefix", c.MQTTConfig.TopicPrefix)
+		if c.MQTTConfig.Discovery {
+			v.required("mqtt.discoveryPrefix", c.MQTTConfig.DiscoveryPrefix)
+		}
+	}
+
+	v.positive("health.livenessTimeout", c.HealthConfig.LivenessTimeout)
+	v.positive("health.readinessWindow", c.HealthConfig.ReadinessWindow)
+
+	if c.ControlAPIConfig.Enable {
+		v.required("controlAPI.token", c.ControlAPIConfig.Token)
+	}
+
+	if c.NetlinkEventsConfig.Enable {
+		v.notNegative("netlinkEvents.debounce", c.NetlinkEventsConfig.Debounce)
+	}
+
+	if c.StateStoreConfig.Enable {
+		v.required("stateStore.path", c.StateStoreConfig.Path)
+		v.notNegative("stateStore.forceReconcileInterval", c.StateStoreConfig.ForceReconcileInterval)
+	}
+
+	if c.StabilityConfig.MinObservations < 1 {
+		v.add("stability.minObservations", "must be at least 1, got %d", c.StabilityConfig.MinObservations)
+	}
+	v.notNegative("stability.minDuration", c.StabilityConfig.MinDuration)
+	v.notNegative("stability.minUpdateInterval", c.StabilityConfig.MinUpdateInterval)
+
+	if c.VaultConfig.Address != "" {
+		v.url("vault.address", c.VaultConfig.Address, "http", "https")
+		v.positive("vault.timeout", c.VaultConfig.Timeout)
+	}
+
+	c.validateIPAddressProviders(v)
+	c.validateDNSProviders(v)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// validateIPAddressProviders Validates that exactly one ip address provider is enabled and its settings
+func (c *Config) validateIPAddressProviders(v *configValidator) {
+	v.exactlyOne("IPAddressProvider", map[string]bool{
+		"staticIPAddressProvider": c.StaticIPAddressProviderConfig.Enable,
+		"urlIPAddressProvider":    c.URLIPAddressProviderConfig.Enable,
+	})
+
+	if c.StaticIPAddressProviderConfig.Enable && net.ParseIP(c.StaticIPAddressProviderConfig.Address) == nil {
+		v.add("staticIPAddressProvider.address", "%q is not a valid ip address", c.StaticIPAddressProviderConfig.Address)
+	}
+
+	if c.URLIPAddressProviderConfig.Enable {
+		// The url is configured without the scheme
+		if strings.Contains(c.URLIPAddressProviderConfig.URL, "://") {
+			v.add("urlIPAddressProvider.url", "must not contain the scheme, use https to choose between http and https")
+		} else {
+			v.url("urlIPAddressProvider.url", "http://"+c.URLIPAddressProviderConfig.URL, "http")
+		}
+		if _, err := regexp.Compile(c.URLIPAddressProviderConfig.Regex); err != nil {
+			v.add("urlIPAddressProvider.regex", "is not a valid regex: %s", err)
+		}
+	}
+}
+
+// validateDNSProviders Validates that exactly one dns provider is enabled and its settings
+func (c *Config) validateDNSProviders(v *configValidator) {
+	v.exactlyOne("DNSProvider", map[string]bool{
+		"cloudflareDNSProvider":   c.CloudflareDNSProviderConfig.Enable,
+		"rfc2136DNSProvider":      c.RFC2136DNSProviderConfig.Enable,
+		"dyndns2DNSProvider":      c.DynDNS2DNSProviderConfig.Enable,
+		"route53DNSProvider":      c.Route53DNSProviderConfig.Enable,
+		"hetznerDNSProvider":      c.HetznerDNSProviderConfig.Enable,
+		"digitalOceanDNSProvider": c.DigitalOceanDNSProviderConfig.Enable,
+		"powerDNSDNSProvider":     c.PowerDNSDNSProviderConfig.Enable,
+		"googleCloudDNSProvider":  c.GoogleCloudDNSProviderConfig.Enable,
+		"azureDNSProvider":        c.AzureDNSProviderConfig.Enable,
+		"desecDNSProvider":        c.DeSECDNSProviderConfig.Enable,
+		"gandiDNSProvider":        c.GandiDNSProviderConfig.Enable,
+		"porkbunDNSProvider":      c.PorkbunDNSProviderConfig.Enable,
+		"ovhDNSProvider":          c.OVHDNSProviderConfig.Enable,
+		"piHoleDNSProvider":       c.PiHoleDNSProviderConfig.Enable,
+		"adGuardHomeDNSProvider":  c.AdGuardHomeDNSProviderConfig.Enable,
+		"hostsFileDNSProvider":    c.HostsFileDNSProviderConfig.Enable,
+		"zoneFileDNSProvider":     c.ZoneFileDNSProviderConfig.Enable,
+		"dnsServer":               c.DNSServerConfig.Enable,
+	})
+
+	if c.CloudflareDNSProviderConfig.Enable {
+		v.required("cloudflareDNSProvider.apiToken", c.CloudflareDNSProviderConfig.APIToken)
+		v.required("cloudflareDNSProvider.zoneID", c.CloudflareDNSProviderConfig.ZoneID)
+		v.hostnames("cloudflareDNSProvider.aRecords", c.CloudflareDNSProviderConfig.ARecords)
+	}
+
+	if r := &c.RFC2136DNSProviderConfig; r.Enable {
+		v.hostPort("rfc2136DNSProvider.server", r.Server)
+		if !validHostname(r.Zone) {
+			v.add("rfc2136DNSProvider.zone", "%q is not a valid zone", r.Zone)
+		}
+		if r.Protocol != "udp" && r.Protocol != "tcp" {
+			v.add("rfc2136DNSProvider.protocol", "must be udp or tcp, got %q", r.Protocol)
+		}
+		if r.TSIGKeyName != "" {
+			if _, ok := rfc2136TSIGAlgorithms[strings.ToLower(r.TSIGAlgorithm)]; !ok {
+				v.add("rfc2136DNSProvider.tsigAlgorithm", "must be hmac-sha256 or hmac-sha512, got %q", r.TSIGAlgorithm)
+			}
+			if _, err := base64.StdEncoding.DecodeString(r.TSIGSecret); err != nil || r.TSIGSecret == "" {
+				v.add("rfc2136DNSProvider.tsigSecret", "must be a base64 encoded key")
+			}
+		}
+		v.positive("rfc2136DNSProvider.timeout", r.Timeout)
+		v.hostnames("rfc2136DNSProvider.aRecords", r.ARecords)
+		v.inZone("rfc2136DNSProvider.aRecords", r.ARecords, r.Zone)
+	}
+
+	if d := &c.DynDNS2DNSProviderConfig; d.Enable {
+		v.url("dyndns2DNSProvider.url", d.URL, "http", "https")
+		v.required("dyndns2DNSProvider.username", d.Username)
+		v.required("dyndns2DNSProvider.password", d.Password)
+		v.required("dyndns2DNSProvider.userAgent", d.UserAgent)
+		if d.Resolver != "" {
+			v.hostPort("dyndns2DNSProvider.resolver", d.Resolver)
+		}
+		v.hostnames("dyndns2DNSProvider.aRecords", d.ARecords)
+	}
+
+	if r := &c.Route53DNSProviderConfig; r.Enable {
+		v.required("route53DNSProvider.hostedZoneID", r.HostedZoneID)
+		if accessKeyID, secretAccessKey, _ := route53Credentials(r); accessKeyID == "" || secretAccessKey == "" {
+			v.add("route53DNSProvider.accessKeyID", "accessKeyID and secretAccessKey or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+		}
+		v.required("route53DNSProvider.region", r.Region)
+		v.url("route53DNSProvider.endpoint", r.Endpoint, "http", "https")
+		if r.TTL < 1 {
+			v.add("route53DNSProvider.ttl", "must be at least 1, got %d", r.TTL)
+		}
+		if r.WaitForSync {
+			v.positive("route53DNSProvider.syncTimeout", r.SyncTimeout)
+			v.positive("route53DNSProvider.syncPollInterval", r.SyncPollInterval)
+		}
+		v.hostnames("route53DNSProvider.aRecords", r.ARecords)
+	}
+
+	if h := &c.HetznerDNSProviderConfig; h.Enable {
+		v.required("hetznerDNSProvider.apiToken", h.APIToken)
+		v.required("hetznerDNSProvider.zoneID", h.ZoneID)
+		v.url("hetznerDNSProvider.baseURL", h.BaseURL, "http", "https")
+		if h.TTL < 0 {
+			v.add("hetznerDNSProvider.ttl", "must not be negative, got %d", h.TTL)
+		}
+		v.hostnames("hetznerDNSProvider.aRecords", h.ARecords)
+	}
+
+	if d := &c.DigitalOceanDNSProviderConfig; d.Enable {
+		v.required("digitalOceanDNSProvider.apiToken", d.APIToken)
+		if !validHostname(d.Domain) {
+			v.add("digitalOceanDNSProvider.domain", "%q is not a valid domain", d.Domain)
+		}
+		v.url("digitalOceanDNSProvider.baseURL", d.BaseURL, "http", "https")
+		if d.TTL < 30 {
+			v.add("digitalOceanDNSProvider.ttl", "must be at least 30, got %d", d.TTL)
+		}
+		v.hostnames("digitalOceanDNSProvider.aRecords", d.ARecords)
+		v.inZone("digitalOceanDNSProvider.aRecords", d.ARecords, d.Domain)
+	}
+
+	if p := &c.PowerDNSDNSProviderConfig; p.Enable {
+		v.url("powerDNSDNSProvider.url", p.URL, "http", "https")
+		v.required("powerDNSDNSProvider.apiKey", p.APIKey)
+		v.required("powerDNSDNSProvider.serverID", p.ServerID)
+		if !validHostname(p.Zone) {
+			v.add("powerDNSDNSProvider.zone", "%q is not a valid zone", p.Zone)
+		}
+		v.hostnames("powerDNSDNSProvider.aRecords", p.ARecords)
+		v.inZone("powerDNSDNSProvider.aRecords", p.ARecords, p.Zone)
+	}
+
+	if g := &c.GoogleCloudDNSProviderConfig; g.Enable {
+		if key, _, err := parseGoogleServiceAccountKey(g.Credentials); err != nil {
+			v.add("googleCloudDNSProvider.credentials", "%s", err)
+		} else if g.Project == "" && key.ProjectID == "" {
+			v.add("googleCloudDNSProvider.project", "is required if the service account key has no project_id")
+		}
+		v.required("googleCloudDNSProvider.managedZone", g.ManagedZone)
+		if g.TTL < 1 {
+			v.add("googleCloudDNSProvider.ttl", "must be at least 1, got %d", g.TTL)
+		}
+		v.url("googleCloudDNSProvider.apiURL", g.APIURL, "http", "https")
+		if g.TokenURL != "" {
+			v.url("googleCloudDNSProvider.tokenURL", g.TokenURL, "http", "https")
+		}
+		v.hostnames("googleCloudDNSProvider.aRecords", g.ARecords)
+	}
+
+	if a := &c.AzureDNSProviderConfig; a.Enable {
+		v.required("azureDNSProvider.tenantID", a.TenantID)
+		v.required("azureDNSProvider.clientID", a.ClientID)
+		v.required("azureDNSProvider.clientSecret", a.ClientSecret)
+		v.required("azureDNSProvider.subscriptionID", a.SubscriptionID)
+		v.required("azureDNSProvider.resourceGroup", a.ResourceGroup)
+		if !validHostname(a.Zone) {
+			v.add("azureDNSProvider.zone", "%q is not a valid zone", a.Zone)
+		}
+		if a.TTL < 1 {
+			v.add("azureDNSProvider.ttl", "must be at least 1, got %d", a.TTL)
+		}
+		v.url("azureDNSProvider.resourceManagerURL", a.ResourceManagerURL, "http", "https")
+		v.url("azureDNSProvider.authorityURL", a.AuthorityURL, "http", "https")
+		v.hostnames("azureDNSProvider.aRecords", a.ARecords)
+		v.inZone("azureDNSProvider.aRecords", a.ARecords, a.Zone)
+	}
+
+	if d := &c.DeSECDNSProviderConfig; d.Enable {
+		v.required("desecDNSProvider.token", d.Token)
+		if !validHostname(d.Domain) {
+			v.add("desecDNSProvider.domain", "%q is not a valid domain", d.Domain)
+		}
+		v.url("desecDNSProvider.baseURL", d.BaseURL, "http", "https")
+		// deSEC rejects TTLs below the minimum of the domain, which is 3600 unless lowered on request
+		if d.TTL < 3600 {
+			v.add("desecDNSProvider.ttl", "must be at least 3600, got %d", d.TTL)
+		}
+		v.hostnames("desecDNSProvider.aRecords", d.ARecords)
+		v.inZone("desecDNSProvider.aRecords", d.ARecords, d.Domain)
+	}
+
+	if g := &c.GandiDNSProviderConfig; g.Enable {
+		v.required("gandiDNSProvider.token", g.Token)
+		if !validHostname(g.Domain) {
+			v.add("gandiDNSProvider.domain", "%q is not a valid domain", g.Domain)
+		}
+		v.url("gandiDNSProvider.baseURL", g.BaseURL, "http", "https")
+		if g.TTL < 300 {
+			v.add("gandiDNSProvider.ttl", "must be at least 300, got %d", g.TTL)
+		}
+		v.hostnames("gandiDNSProvider.aRecords", g.ARecords)
+		v.inZone("gandiDNSProvider.aRecords", g.ARecords, g.Domain)
+	}
+
+	if p := &c.PorkbunDNSProviderConfig; p.Enable {
+		v.required("porkbunDNSProvider.apiKey", p.APIKey)
+		v.required("porkbunDNSProvider.secretAPIKey", p.SecretAPIKey)
+		if !validHostname(p.Domain) {
+			v.add("porkbunDNSProvider.domain", "%q is not a valid domain", p.Domain)
+		}
+		v.url("porkbunDNSProvider.baseURL", p.BaseURL, "http", "https")
+		if p.TTL < 600 {
+			v.add("porkbunDNSProvider.ttl", "must be at least 600, got %d", p.TTL)
+		}
+		v.hostnames("porkbunDNSProvider.aRecords", p.ARecords)
+		v.inZone("porkbunDNSProvider.aRecords", p.ARecords, p.Domain)
+	}
+
+	if o := &c.OVHDNSProviderConfig; o.Enable {
+		v.url("ovhDNSProvider.baseURL", o.BaseURL, "http", "https")
+		v.required("ovhDNSProvider.applicationKey", o.ApplicationKey)
+		v.required("ovhDNSProvider.applicationSecret", o.ApplicationSecret)
+		v.required("ovhDNSProvider.consumerKey", o.ConsumerKey)
+		if !validHostname(o.Zone) {
+			v.add("ovhDNSProvider.zone", "%q is not a valid zone", o.Zone)
+		}
+		if o.TTL < 0 {
+			v.add("ovhDNSProvider.ttl", "must not be negative, got %d", o.TTL)
+		}
+		v.hostnames("ovhDNSProvider.aRecords", o.ARecords)
+		v.inZone("ovhDNSProvider.aRecords", o.ARecords, o.Zone)
+	}
+
+	if p := &c.PiHoleDNSProviderConfig; p.Enable {
+		v.url("piHoleDNSProvider.url", p.URL, "http", "https")
+		v.hostnames("piHoleDNSProvider.aRecords", p.ARecords)
+	}
+
+	if a := &c.AdGuardHomeDNSProviderConfig; a.Enable {
+		v.url("adGuardHomeDNSProvider.url", a.URL, "http", "https")
+		v.hostnames("adGuardHomeDNSProvider.aRecords", a.ARecords)
+	}
+
+	if h := &c.HostsFileDNSProviderConfig; h.Enable {
+		v.required("hostsFileDNSProvider.path", h.Path)
+		if h.Format != "hosts" && h.Format != "dnsmasq" {
+			v.add("hostsFileDNSProvider.format", "must be hosts or dnsmasq, got %q", h.Format)
+		}
+		if _, ok := hostsFileSignals[h.Signal]; !ok && h.PIDFile != "" {
+			v.add("hostsFileDNSProvider.signal", "must be one of SIGHUP, SIGINT, SIGQUIT or SIGTERM, got %q", h.Signal)
+		}
+		v.hostnames("hostsFileDNSProvider.aRecords", h.ARecords)
+	}
+
+	if z := &c.ZoneFileDNSProviderConfig; z.Enable {
+		v.required("zoneFileDNSProvider.path", z.Path)
+		if !validHostname(z.Origin) {
+			v.add("zoneFileDNSProvider.origin", "%q is not a valid zone", z.Origin)
+		}
+		if t := strings.ToUpper(z.RecordType); t != "A" && t != "AAAA" {
+			v.add("zoneFileDNSProvider.recordType", "must be A or AAAA, got %q", z.RecordType)
+		}
+		if z.SerialFormat != "date" && z.SerialFormat != "increment" {
+			v.add("zoneFileDNSProvider.serialFormat", "must be date or increment, got %q", z.SerialFormat)
+		}
+		if len(z.ReloadCommand) > 0 {
+			v.positive("zoneFileDNSProvider.reloadTimeout", z.ReloadTimeout)
+		}
+		v.hostnames("zoneFileDNSProvider.aRecords", z.ARecords)
+		v.inZone("zoneFileDNSProvider.aRecords", z.ARecords, z.Origin)
+	}
+
+	if d := &c.DNSServerConfig; d.Enable {
+		// The host may be empty to listen on all addresses
+		if _, port, err := net.SplitHostPort(d.Listen); err != nil {
+			v.add("dnsServer.listen", "%q must have the form [host]:port", d.Listen)
+		} else if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+			v.add("dnsServer.listen", "%q is not a valid port", port)
+		}
+		if !validHostname(d.Zone) {
+			v.add("dnsServer.zone", "%q is not a valid zone", d.Zone)
+		}
+		if len(d.Nameservers) == 0 {
+			v.add("dnsServer.nameservers", "at least one name server is required")
+		}
+		v.hostnames("dnsServer.nameservers", d.Nameservers)
+		if d.TTL < 1 {
+			v.add("dnsServer.ttl", "must be at least 1, got %d", d.TTL)
+		}
+		for name := range d.TXTRecords {
+			if !validHostname(name) || validHostname(d.Zone) && !dns.IsSubDomain(dns.Fqdn(d.Zone), dns.Fqdn(name)) {
+				v.add("dnsServer.txtRecords."+name, "%q is not a valid name of the zone %s", name, d.Zone)
+			}
+		}
+		v.hostnames("dnsServer.aRecords", d.ARecords)
+		v.inZone("dnsServer.aRecords", d.ARecords, d.Zone)
+	}
+}
+
+// validateNotifications Validates the settings of all enabled notifiers
+func (c *Config) validateNotifications(v *configValidator) {
+	n := &c.NotificationsConfig
+	if n.FailureThreshold < 1 {
+		v.add("notifications.failureThreshold", "must be at least 1, got %d", n.FailureThreshold)
+	}
+	v.positive("notifications.timeout", n.Timeout)
+
+	if n.Webhook.Enable {
+		v.url("notifications.webhook.url", n.Webhook.URL, "http", "https")
+		if _, err := parseWebhookTemplate(n.Webhook.Template); err != nil {
+			v.add("notifications.webhook.template", "is not a valid template: %s", err)
+		}
+		v.events("notifications.webhook.events", n.Webhook.Events)
+	}
+	if n.Email.Enable {
+		v.required("notifications.email.host", n.Email.Host)
+		v.required("notifications.email.from", n.Email.From)
+		if len(n.Email.To) == 0 {
+			v.add("notifications.email.to", "at least one recipient is required")
+		}
+		v.events("notifications.email.events", n.Email.Events)
+	}
+	if n.Ntfy.Enable {
+		v.url("notifications.ntfy.url", n.Ntfy.URL, "http", "https")
+		v.required("notifications.ntfy.topic", n.Ntfy.Topic)
+		v.events("notifications.ntfy.events", n.Ntfy.Events)
+	}
+	if n.Gotify.Enable {
+		v.url("notifications.gotify.url", n.Gotify.URL, "http", "https")
+		v.required("notifications.gotify.token", n.Gotify.Token)
+		v.events("notifications.gotify.events", n.Gotify.Events)
+	}
+	if n.Slack.Enable {
+		v.url("notifications.slack.webhookURL", n.Slack.WebhookURL, "https")
+		v.events("notifications.slack.events", n.Slack.Events)
+	}
+	if n.Discord.Enable {
+		v.url("notifications.discord.webhookURL", n.Discord.WebhookURL, "https")
+		v.events("notifications.discord.events", n.Discord.Events)
+	}
+	if n.Matrix.Enable {
+		v.url("notifications.matrix.homeserver", n.Matrix.Homeserver, "http", "https")
+		v.required("notifications.matrix.accessToken", n.Matrix.AccessToken)
+		v.required("notifications.matrix.roomID", n.Matrix.RoomID)
+		v.events("notifications.matrix.events", n.Matrix.Events)
+	}
+	if n.Telegram.Enable {
+		v.url("notifications.telegram.apiURL", n.Telegram.APIURL, "http", "https")
+		v.required("notifications.telegram.botToken", n.Telegram.BotToken)
+		v.required("notifications.telegram.chatID", n.Telegram.ChatID)
+		v.events("notifications.telegram.events", n.Telegram.Events)
+	}
+}
+
+// validHostname Returns true if the value is a hostname, the first label may be a wildcard
+func validHostname(h string) bool {
+	h = strings.TrimSuffix(h, ".")
+	if h == "" || len(h) > 253 {
+		return false
+	}
+	for i, label := range strings.Split(h, ".") {
+		if i == 0 && label == "*" {
+			continue
+		}
+		if !hostnameLabel.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateConfigFile Strictly decodes the config file rejecting unknown fields, applies the environment and validates the result,
+// errors are returned as ConfigErrors with the line of the offending setting. No file is read if the path is empty.
+func ValidateConfigFile(configPath string) (*Config, error) {
+	var b []byte
+	if configPath != "" {
+		var err error
+		if b, err = os.ReadFile(configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, yamlConfigErrors(err)
+	}
+
+	c := defaultConfig
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, yamlConfigErrors(err)
+	}
+
+	if err := gatherFromEnv(&c); err != nil {
+		return nil, ConfigErrors{{Message: err.Error()}}
+	}
+
+	err := resolveSecrets(&c)
+	if err == nil {
+		err = c.Validate()
+	}
+
+	var errs ConfigErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			e.Line = yamlLine(&root, e.Path)
+		}
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		return &c, errs
+	}
+
+	return &c, nil
+}
+
+// yamlConfigErrors Converts a yaml.v3 decoding error into ConfigErrors with line numbers
+func yamlConfigErrors(err error) ConfigErrors {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	errs := ConfigErrors{}
+	for _, m := range messages {
+		e := &ConfigError{Message: m}
+		if match := yamlErrorLine.FindStringSubmatch(m); match != nil {
+			e.Line, _ = strconv.Atoi(match[1])
+			e.Message = match[2]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// yamlLine Returns the line of the setting at path, or of its closest ancestor present in the document
+func yamlLine(root *yaml.Node, path string) int {
+	n := root
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if path == "" {
+		return 0
+	}
+
+	line := 0
+	for _, segment := range strings.Split(strings.ReplaceAll(path, "[", ".["), ".") {
+		var next *yaml.Node
+		if strings.HasPrefix(segment, "[") {
+			i, err := strconv.Atoi(strings.Trim(segment, "[]"))
+			if err == nil && n.Kind == yaml.SequenceNode && i < len(n.Content) {
+				next = n.Content[i]
+				line = next.Line
+			}
+		} else if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == segment {
+					line = n.Content[i].Line
+					next = n.Content[i+1]
+					break
+				}
+			}
+		}
+		if next == nil {
+			return line
+		}
+		n = next
+	}
+	return line
+}
+
+// RedactedConfig Returns a copy of the config with the values of all fields tagged as secret replaced
+func RedactedConfig(c *Config) *Config {
+	r := *c
+	redact(reflect.ValueOf(&r).Elem())
+	return &r
+}
+
+// redact Replaces non-empty secret fields of the struct, slices are copied before being modified
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		switch {
+		case t.Field(i).Tag.Get("secret") == "true" && f.Kind() == reflect.String:
+			if f.String() != "" {
+				f.SetString(redacted)
+			}
+		case f.Kind() == reflect.Struct:
+			redact(f)
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Struct && !f.IsNil():
+			s := reflect.MakeSlice(f.Type(), f.Len(), f.Len())
+			reflect.Copy(s, f)
+			for j := 0; j < s.Len(); j++ {
+				redact(s.Index(j))
+			}
+			f.Set(s)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func validateTestConfigFile(t *testing.T, content string) (*Config, ConfigErrors) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ValidateConfigFile(path)
+	var errs ConfigErrors
+	if err != nil && !errors.As(err, &errs) {
+		t.Fatalf("unexpected error %s", err)
+	}
+	return c, errs
+}
+
+// TestValidateConfigFileValid tests that a valid config file results in no errors
+func TestValidateConfigFileValid(t *testing.T) {
+	_, errs := validateTestConfigFile(t, strings.Replace(reloadTestConfig, "%s", "1m", 1))
+	if len(errs) != 0 {
+		t.Errorf("got %s, wanted no errors", errs)
+	}
+}
+
+// TestValidateConfigFileUnknownFields tests that unknown fields are rejected with their line
+func TestValidateConfigFileUnknownFields(t *testing.T) {
+	_, errs := validateTestConfigFile(t, "waitInterval: 1m\nwaitIntervall: 2m\nmetricsServer:\n  enabled: true\n")
+	if len(errs) != 2 {
+		t.Fatalf("got %s, wanted 2 errors", errs)
+	}
+	if errs[0].Line != 2 || !strings.Contains(errs[0].Message, "waitIntervall") {
+		t.Errorf("got %s, wanted unknown field waitIntervall on line 2", errs[0])
+	}
+	if errs[1].Line != 4 || !strings.Contains(errs[1].Message, "enabled") {
+		t.Errorf("got %s, wanted unknown field enabled on line 4", errs[1])
+	}
+}
+
+// TestValidateConfigFileInvalid tests that invalid settings are reported with their path and line
+func TestValidateConfigFileInvalid(t *testing.T) {
+	_, errs := validateTestConfigFile(t, `
+waitInterval: "0s"
+urlIPAddressProvider:
+  enable: true
+  url: "example.com/ip"
+  regex: "(["
+cloudflareDNSProvider:
+  enable: true
+  zoneID: "zone"
+  aRecords:
+    - "example.com"
+    - "not a hostname"
+notifications:
+  webhook:
+    enable: true
+    url: "ftp://example.com"
+    events:
+      - "address_changed"
+      - "unknown"
+`)
+
+	want := map[string]int{
+		"waitInterval":                      2,
+		"urlIPAddressProvider.regex":        6,
+		"cloudflareDNSProvider.apiToken":    7,
+		"cloudflareDNSProvider.aRecords[1]": 12,
+		"notifications.webhook.url":         16,
+		"notifications.webhook.events[1]":   19,
+	}
+	got := map[string]int{}
+	for _, e := range errs {
+		got[e.Path] = e.Line
+	}
+	for path, line := range want {
+		if got[path] != line {
+			t.Errorf("got line %d for %s, wanted %d", got[path], path, line)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("got %s, wanted %d errors", errs, len(want))
+	}
+}
+
+// TestValidateHooks tests that hooks are validated in a fixed order and the default timeout has to be positive
+func TestValidateHooks(t *testing.T) {
+	c := defaultConfig
+	c.StaticIPAddressProviderConfig.Enable = true
+	c.StaticIPAddressProviderConfig.Address = "10.0.0.1"
+	c.CloudflareDNSProviderConfig.Enable = true
+	c.CloudflareDNSProviderConfig.APIToken = "token"
+	c.CloudflareDNSProviderConfig.ZoneID = "zone"
+	c.CloudflareDNSProviderConfig.ARecords = StringList{"example.com"}
+	c.HooksConfig.Timeout = 0
+	c.HooksConfig.PreUpdate = []HookConfig{{}, {Command: []string{"true"}, Timeout: -1}}
+	c.HooksConfig.PostUpdate = []HookConfig{{}}
+
+	want := []string{"hooks.timeout", "hooks.preUpdate[0].command", "hooks.preUpdate[1].timeout", "hooks.postUpdate[0].command"}
+	for n := 0; n < 10; n++ {
+		var errs ConfigErrors
+		if !errors.As(c.Validate(), &errs) {
+			t.Fatal("expected ConfigErrors")
+		}
+		var got []string
+		for _, e := range errs {
+			got = append(got, e.Path)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("got %v, wanted %v", got, want)
+		}
+	}
+}
+
+// TestValidateDeSECTTL tests that the deSEC TTL defaults to and has to be at least 3600
+func TestValidateDeSECTTL(t *testing.T) {
+	c := defaultConfig
+	c.StaticIPAddressProviderConfig.Enable = true
+	c.StaticIPAddressProviderConfig.Address = "10.0.0.1"
+	c.DeSECDNSProviderConfig.Enable = true
+	c.DeSECDNSProviderConfig.Token = "token"
+	c.DeSECDNSProviderConfig.Domain = "example.com"
+	c.DeSECDNSProviderConfig.ARecords = StringList{"home.example.com"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	c.DeSECDNSProviderConfig.TTL = 300
+	var errs ConfigErrors
+	if !errors.As(c.Validate(), &errs) || len(errs) != 1 || errs[0].Path != "desecDNSProvider.ttl" {
+		t.Errorf("got %v, wanted an error for desecDNSProvider.ttl", errs)
+	}
+}
+
+// TestValidateProviderCombinations tests that exactly one provider of each kind has to be enabled
+func TestValidateProviderCombinations(t *testing.T) {
+	c := defaultConfig
+	c.StaticIPAddressProviderConfig.Enable = true
+	c.URLIPAddressProviderConfig.Enable = true
+
+	var errs ConfigErrors
+	if !errors.As(c.Validate(), &errs) {
+		t.Fatal("expected ConfigErrors")
+	}
+
+	paths := map[string]bool{}
+	for _, e := range errs {
+		paths[e.Path] = true
+	}
+	for _, p := range []string{"staticIPAddressProvider.enable", "urlIPAddressProvider.enable", ""} {
+		if !paths[p] {
+			t.Errorf("got %s, wanted an error for %q", errs, p)
+		}
+	}
+}
+
+// TestRedactedConfig tests that secrets are replaced without modifying the original config
+func TestRedactedConfig(t *testing.T) {
+	c := defaultConfig
+	c.CloudflareDNSProviderConfig.APIToken = "token"
+	c.NotificationsConfig.Email.Password = "password"
+	c.HooksConfig.PreUpdate = []HookConfig{{Command: []string{"true"}}}
+
+	r := RedactedConfig(&c)
+	if got := r.CloudflareDNSProviderConfig.APIToken; got != redacted {
+		t.Errorf("got %s, wanted %s", got, redacted)
+	}
+	if got := r.NotificationsConfig.Email.Password; got != redacted {
+		t.Errorf("got %s, wanted %s", got, redacted)
+	}
+	if got := r.ControlAPIConfig.Token; got != "" {
+		t.Errorf("got %s, wanted empty secrets to stay empty", got)
+	}
+	if got := c.CloudflareDNSProviderConfig.APIToken; got != "token" {
+		t.Errorf("got %s, wanted the original config to be unchanged", got)
+	}
+}
@@ -0,0 +1,387 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+// ZoneFileDNSProviderConfig Configuration for the zone file DNS Provider
+type ZoneFileDNSProviderConfig struct {
+	// Switch to enable or disable this provider
+	Enable bool `yaml:"enable" envconfig:"DDNS_ZONEFILE_PROVIDER_ENABLE" required:"false"`
+
+	// Path of the RFC 1035 zone file
+	Path string `yaml:"path" envconfig:"DDNS_ZONEFILE_PROVIDER_PATH" required:"false"`
+
+	// Origin of the zone file, relative names are resolved against it until a $ORIGIN directive
+	Origin string `yaml:"origin" envconfig:"DDNS_ZONEFILE_PROVIDER_ORIGIN" required:"false"`
+
+	// Type of the managed records, A or AAAA
+	RecordType string `yaml:"recordType" envconfig:"DDNS_ZONEFILE_PROVIDER_RECORD_TYPE" required:"false"`
+
+	// TTL of added records in seconds, the $TTL of the zone is used if 0
+	TTL uint32 `yaml:"ttl" envconfig:"DDNS_ZONEFILE_PROVIDER_TTL" required:"false"`
+
+	// Format of the SOA serial, date for YYYYMMDDnn or increment
+	SerialFormat string `yaml:"serialFormat" envconfig:"DDNS_ZONEFILE_PROVIDER_SERIAL_FORMAT" required:"false"`
+
+	// Executable and arguments of the command run after the zone file was written, not interpreted by a shell
+	ReloadCommand StringList `yaml:"reloadCommand" envconfig:"DDNS_ZONEFILE_PROVIDER_RELOAD_COMMAND" required:"false"`
+
+	// Go duration after which the reload command is killed
+	ReloadTimeout time.Duration `yaml:"reloadTimeout" envconfig:"DDNS_ZONEFILE_PROVIDER_RELOAD_TIMEOUT" required:"false"`
+
+	// List of A Records
+	ARecords StringList `yaml:"aRecords" envconfig:"DDNS_ZONEFILE_PROVIDER_RECORDS" required:"false"`
+}
+
+var defaultZoneFileDNSProviderConfig = &ZoneFileDNSProviderConfig{
+	Enable:        false,
+	Path:          "",
+	Origin:        "",
+	RecordType:    "A",
+	TTL:           0,
+	SerialFormat:  "date",
+	ReloadCommand: nil,
+	ReloadTimeout: 30 * time.Second,
+	ARecords:      nil,
+}
+
+// zoneFileToken A token of the zone file, identified by its line and byte offsets so that it can be replaced in place
+type zoneFileToken struct {
+	text  string
+	line  int
+	start int
+	end   int
+}
+
+// zoneFile The lines of a zone file with the positions of the SOA serial and the managed records
+type zoneFile struct {
+	lines   []string
+	serial  *zoneFileToken
+	records map[string]zoneFileToken
+}
+
+// ZoneFileDNSProvider zone file DNS Provider, records are rewritten in place so that comments and formatting are kept
+type ZoneFileDNSProvider struct {
+	path          string
+	origin        string
+	recordType    string
+	ttl           uint32
+	serialFormat  string
+	reloadCommand []string
+	reloadTimeout time.Duration
+	aRecords      []string
+
+	mu sync.Mutex
+
+	// reloadPending Is true if the zone file was written since the reload command last succeeded
+	reloadPending bool
+
+	// now Returns the current time, the date of the serial is based on it
+	now func() time.Time
+}
+
+// NewZoneFileDNSProvider Returns an instance of ZoneFileDNSProvider based on the passed configuration
+func NewZoneFileDNSProvider(config *ZoneFileDNSProviderConfig) *ZoneFileDNSProvider {
+	return &ZoneFileDNSProvider{
+		path:          config.Path,
+		origin:        dns.Fqdn(strings.ToLower(config.Origin)),
+		recordType:    strings.ToUpper(config.RecordType),
+		ttl:           config.TTL,
+		serialFormat:  config.SerialFormat,
+		reloadCommand: config.ReloadCommand,
+		reloadTimeout: config.ReloadTimeout,
+		aRecords:      config.ARecords,
+		now:           time.Now,
+	}
+}
+
+// GetARecordAddresses Return the RecordAddressMappings with the addresses of the zone file for names specified in the
+// configuration, the ip address is empty if a name has no record of the configured type yet. A failed reload command is
+// retried first, as the zone file already contains the addresses the name server may not serve yet.
+func (z *ZoneFileDNSProvider) GetARecordAddresses(ctx context.Context) ([]RecordAddressMapping, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if err := z.reloadIfPending(ctx); err != nil {
+		return nil, err
+	}
+
+	f, err := z.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []RecordAddressMapping
+	for _, name := range z.aRecords {
+		fqdn := dns.Fqdn(strings.ToLower(name))
+		ms = append(ms, RecordAddressMapping{ID: fqdn, ARecord: name, IPAddress: f.records[fqdn].text})
+	}
+
+	return ms, nil
+}
+
+// SetARecordAddress Replace the address of the first record of the name with the provided ip address or append a record
+// if there is none, bump the SOA serial, atomically replace the zone file and run the reload command if configured
+func (z *ZoneFileDNSProvider) SetARecordAddress(ctx context.Context, ipAddress string, m RecordAddressMapping) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	log.Info().Msgf("Setting %s record %s to %s", z.recordType, m.ARecord, ipAddress)
+
+	if ip := net.ParseIP(ipAddress); ip == nil || (ip.To4() != nil) != (z.recordType == "A") {
+		return fmt.Errorf("%s is not a valid address for a %s record", ipAddress, z.recordType)
+	}
+
+	f, err := z.read()
+	if err != nil {
+		return err
+	}
+	if f.serial == nil {
+		return fmt.Errorf("%s has no SOA record", z.path)
+	}
+
+	serial, err := strconv.ParseUint(f.serial.text, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid SOA serial: %w", f.serial.text, err)
+	}
+	f.replace(*f.serial, strconv.FormatUint(uint64(z.nextSerial(uint32(serial))), 10))
+
+	fqdn := dns.Fqdn(strings.ToLower(m.ARecord))
+	if record, ok := f.records[fqdn]; ok {
+		f.replace(record, ipAddress)
+	} else {
+		f.append(z.newRecord(fqdn, ipAddress))
+	}
+
+	content := strings.Join(f.lines, "\n")
+	if err := z.check(content); err != nil {
+		return fmt.Errorf("refusing to write an invalid zone file: %w", err)
+	}
+
+	perm := fs.FileMode(0o644)
+	if info, err := os.Stat(z.path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(z.path, []byte(content), perm); err != nil {
+		return fmt.Errorf("could not write %s: %w", z.path, err)
+	}
+
+	z.reloadPending = len(z.reloadCommand) > 0
+	return z.reloadIfPending(ctx)
+}
+
+// nextSerial Returns the serial following the passed one, date based serials never go backwards
+func (z *ZoneFileDNSProvider) nextSerial(serial uint32) uint32 {
+	if z.serialFormat == "date" {
+		y, m, d := z.now().Date()
+		if today := uint32(y*1000000 + int(m)*10000 + d*100); today > serial {
+			return today
+		}
+	}
+	return serial + 1
+}
+
+// newRecord Returns the line of a record of the name pointing to the ip address
+func (z *ZoneFileDNSProvider) newRecord(fqdn string, ipAddress string) string {
+	if z.ttl == 0 {
+		return fmt.Sprintf("%s\tIN\t%s\t%s", fqdn, z.recordType, ipAddress)
+	}
+	return fmt.Sprintf("%s\t%d\tIN\t%s\t%s", fqdn, z.ttl, z.recordType, ipAddress)
+}
+
+// check Returns an error if the content is not a valid zone file
+func (z *ZoneFileDNSProvider) check(content string) error {
+	zp := dns.NewZoneParser(strings.NewReader(content), z.origin, z.path)
+	for _, ok := zp.Next(); ok; _, ok = zp.Next() {
+	}
+	return zp.Err()
+}
+
+// reloadIfPending Run the reload command if the zone file was written since it last succeeded
+func (z *ZoneFileDNSProvider) reloadIfPending(ctx context.Context) error {
+	if !z.reloadPending {
+		return nil
+	}
+	if err := z.reload(ctx); err != nil {
+		return err
+	}
+	z.reloadPending = false
+	return nil
+}
+
+// reload Run the reload command and return an error if it fails
+func (z *ZoneFileDNSProvider) reload(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, z.reloadTimeout)
+	defer cancel()
+
+	log.Info().Msgf("Running reload command %s", z.reloadCommand[0])
+	out, err := exec.CommandContext(ctx, z.reloadCommand[0], z.reloadCommand[1:]...).CombinedOutput()
+	if len(out) > 0 {
+		log.Debug().Msgf("Output of reload command %s: %s", z.reloadCommand[0], out)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", z.reloadTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("reload command %s failed: %w", z.reloadCommand[0], err)
+	}
+	return nil
+}
+
+// read Parses the zone file for the SOA serial and the first record of the configured type of every name
+func (z *ZoneFileDNSProvider) read() (*zoneFile, error) {
+	b, err := os.ReadFile(z.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("zone file %s does not exist", z.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", z.path, err)
+	}
+
+	f := &zoneFile{lines: strings.Split(string(b), "\n"), records: map[string]zoneFileToken{}}
+	origin, owner := z.origin, z.origin
+
+	var tokens []zoneFileToken
+	blankOwner, depth := false, 0
+	for i, line := range f.lines {
+		if depth == 0 {
+			tokens = nil
+			blankOwner = strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		}
+		lineTokens, d := zoneFileTokens(line, i)
+		tokens = append(tokens, lineTokens...)
+		if depth += d; depth > 0 || len(tokens) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(tokens[0].text, "$") {
+			switch strings.ToUpper(tokens[0].text) {
+			case "$ORIGIN":
+				if len(tokens) > 1 {
+					origin = zoneFileName(tokens[1].text, origin)
+				}
+			case "$INCLUDE":
+				return nil, fmt.Errorf("%s:%d: $INCLUDE is not supported", z.path, i+1)
+			}
+			continue
+		}
+
+		if !blankOwner {
+			owner = zoneFileName(tokens[0].text, origin)
+			tokens = tokens[1:]
+		}
+
+		// The TTL and class are optional and may appear in any order before the type
+		for len(tokens) > 0 && (tokens[0].text[0] >= '0' && tokens[0].text[0] <= '9' || dns.StringToClass[strings.ToUpper(tokens[0].text)] != 0) {
+			tokens = tokens[1:]
+		}
+		if len(tokens) < 2 {
+			continue
+		}
+
+		switch rrType := strings.ToUpper(tokens[0].text); {
+		case rrType == "SOA" && f.serial == nil && len(tokens) > 3:
+			serial := tokens[3]
+			f.serial = &serial
+		case rrType == z.recordType:
+			if _, ok := f.records[owner]; !ok {
+				f.records[owner] = tokens[1]
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// replace Replace the text of the token
+func (f *zoneFile) replace(t zoneFileToken, text string) {
+	f.lines[t.line] = f.lines[t.line][:t.start] + text + f.lines[t.line][t.end:]
+}
+
+// append Append the line to the end of the zone file, which keeps ending with a new line
+func (f *zoneFile) append(line string) {
+	if n := len(f.lines); n > 0 && f.lines[n-1] == "" {
+		f.lines = append(f.lines[:n-1], line, "")
+		return
+	}
+	f.lines = append(f.lines, line, "")
+}
+
+// zoneFileName Returns the lower case fully qualified name of an owner, relative names are resolved against the origin
+func zoneFileName(name string, origin string) string {
+	name = strings.ToLower(name)
+	if name == "@" {
+		return origin
+	}
+	if dns.IsFqdn(name) {
+		return name
+	}
+	return name + "." + origin
+}
+
+// zoneFileTokens Returns the tokens of the line without comments and parentheses, along with the change of the
+// parentheses depth
+func zoneFileTokens(line string, lineIndex int) ([]zoneFileToken, int) {
+	var tokens []zoneFileToken
+	depth := 0
+	start := -1
+	inQuotes := false
+
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, zoneFileToken{text: line[start:end], line: lineIndex, start: start, end: end})
+			start = -1
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			if start < 0 {
+				start = i
+			}
+			i++
+		case inQuotes:
+			inQuotes = c != '"'
+		case c == '"':
+			if start < 0 {
+				start = i
+			}
+			inQuotes = true
+		case c == ';':
+			flush(i)
+			return tokens, depth
+		case c == '(' || c == ')':
+			flush(i)
+			if c == '(' {
+				depth++
+			} else {
+				depth--
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			flush(i)
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(line))
+
+	return tokens, depth
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testZoneFile = `; Zone of example.com, pulled by the secondaries
+$TTL 3600
+@	IN	SOA	ns1.example.com. hostmaster.example.com. (
+		2024010107 ; serial
+		7200       ; refresh
+		3600       ; retry
+		1209600    ; expire
+		300 )      ; minimum
+	IN	NS	ns1.example.com.
+	IN	TXT	"v=spf1 -all; (not a comment)"
+@	300	IN	A	10.0.0.1 ; apex
+ns1	IN	A	10.0.0.53
+
+$ORIGIN home.example.com.
+@		IN  A     10.0.0.2   ; router
+nas	60	IN  AAAA  fd00::2
+`
+
+// TestZoneFileDNSProvider tests that records are replaced in place, missing records are appended and the serial is bumped
+func TestZoneFileDNSProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.com.zone")
+	if err := os.WriteFile(path, []byte(testZoneFile), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	c := *defaultZoneFileDNSProviderConfig
+	c.Path = path
+	c.Origin = "example.com"
+	c.ARecords = StringList{"example.com", "home.example.com", "new.example.com"}
+	p := NewZoneFileDNSProvider(&c)
+	p.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	ms, err := p.GetARecordAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com": "10.0.0.1", "home.example.com": "10.0.0.2", "new.example.com": ""}
+	for _, m := range ms {
+		if m.IPAddress != want[m.ARecord] {
+			t.Errorf("got %s for %s, wanted %s", m.IPAddress, m.ARecord, want[m.ARecord])
+		}
+	}
+
+	for _, m := range ms {
+		if err := p.SetARecordAddress(context.Background(), "192.0.2.1", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantZoneFile := strings.NewReplacer(
+		"2024010107 ; serial", "2024010110 ; serial",
+		"@	300	IN	A	10.0.0.1 ; apex", "@	300	IN	A	192.0.2.1 ; apex",
+		"@		IN  A     10.0.0.2   ; router", "@		IN  A     192.0.2.1   ; router",
+	).Replace(testZoneFile) + "new.example.com.\tIN\tA\t192.0.2.1\n"
+	b, _ := os.ReadFile(path)
+	if string(b) != wantZoneFile {
+		t.Errorf("got %s, wanted %s", b, wantZoneFile)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Errorf("got %s, wanted %s", info.Mode().Perm(), os.FileMode(0o640))
+	}
+
+	if err := p.SetARecordAddress(context.Background(), "fd00::1", ms[0]); err == nil {
+		t.Error("got nil, wanted an error for an IPv6 address")
+	}
+}
+
+// TestZoneFileDNSProviderSerial tests that date based serials start a new day and never go backwards
+func TestZoneFileDNSProviderSerial(t *testing.T) {
+	p := NewZoneFileDNSProvider(defaultZoneFileDNSProviderConfig)
+	p.now = func() time.Time { return time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		format string
+		serial uint32
+		want   uint32
+	}{
+		{"date", 2024010107, 2024031500},
+		{"date", 2024031500, 2024031501},
+		{"date", 2025010100, 2025010101},
+		{"date", 42, 2024031500},
+		{"increment", 42, 43},
+		{"increment", 4294967295, 0},
+	}
+
+	for _, test := range tests {
+		p.serialFormat = test.format
+		if got := p.nextSerial(test.serial); got != test.want {
+			t.Errorf("got %d for %s serial %d, wanted %d", got, test.format, test.serial, test.want)
+		}
+	}
+}
+
+// TestZoneFileDNSProviderReload tests that the reload command runs after a write, its failure is returned and it is
+// retried until it succeeds
+func TestZoneFileDNSProviderReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "example.com.zone")
+	if err := os.WriteFile(path, []byte(testZoneFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(dir, "reloaded")
+
+	c := *defaultZoneFileDNSProviderConfig
+	c.Path = path
+	c.Origin = "example.com"
+	c.SerialFormat = "increment"
+	c.ReloadCommand = StringList{"touch", marker}
+	c.ARecords = StringList{"example.com"}
+	p := NewZoneFileDNSProvider(&c)
+
+	m := RecordAddressMapping{ID: "example.com.", ARecord: "example.com", IPAddress: "10.0.0.1"}
+	if err := p.SetARecordAddress(context.Background(), "192.0.2.1", m); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("got %s, wanted the reload command to run", err)
+	}
+
+	p.reloadCommand = []string{"false"}
+	if err := p.SetARecordAddress(context.Background(), "192.0.2.2", m); err == nil || !strings.Contains(err.Error(), "reload command false failed") {
+		t.Errorf("got %v, wanted a reload error", err)
+	}
+	if _, err := p.GetARecordAddresses(context.Background()); err == nil {
+		t.Error("got nil, wanted the failed reload to be retried")
+	}
+
+	_ = os.Remove(marker)
+	p.reloadCommand = []string{"touch", marker}
+	if _, err := p.GetARecordAddresses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("got %s, wanted the reload command to be retried", err)
+	}
+
+	_ = os.Remove(marker)
+	if _, err := p.GetARecordAddresses(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("got the reload command to run again, wanted it to run only until it succeeded")
+	}
+}